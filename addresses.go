@@ -1,11 +1,255 @@
 package config
 
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
 // Addresses stores the (string) multiaddr addresses for the node.
+//
+// Announce replaces the automatically detected swarm addresses, while
+// AppendAnnounce is announced in addition to them. Both may contain {token}
+// templates, see ExpandAnnounce.
 type Addresses struct {
-	Swarm      []string // addresses for the swarm to listen on
-	Announce   []string // swarm addresses to announce to the network
-	NoAnnounce []string // swarm addresses not to announce to the network
-	API        Strings  // address for the local API (RPC)
-	Gateway    Strings  // address to listen on for BTFS HTTP object gateway
-	RemoteAPI  Strings  // address to listen for remote API (RPC over libp2p)
+	Swarm          []string // addresses for the swarm to listen on
+	Announce       []string // swarm addresses to announce to the network
+	AppendAnnounce []string // swarm addresses to announce on top of the detected ones
+	NoAnnounce     []string // swarm addresses not to announce to the network
+	API            Strings  // address for the local API (RPC)
+	Gateway        Strings  // address to listen on for BTFS HTTP object gateway
+	RemoteAPI      Strings  // address to listen for remote API (RPC over libp2p)
+}
+
+// Validate parses every configured address and reports all malformed entries
+// along with the field they belong to. NoAnnounce is the only field that
+// accepts CIDR ranges such as "/ip4/192.168.0.0/ipcidr/16" or
+// "/ip6/fc00::/ipcidr/7"; every other field needs concrete addresses.
+func (a Addresses) Validate() error {
+	var errs ValidationErrors
+	fields := []struct {
+		name  string
+		addrs []string
+	}{
+		{"Swarm", a.Swarm},
+		{"Announce", a.Announce},
+		{"AppendAnnounce", a.AppendAnnounce},
+		{"NoAnnounce", a.NoAnnounce},
+		{"API", a.API},
+		{"Gateway", a.Gateway},
+		{"RemoteAPI", a.RemoteAPI},
+	}
+	for _, f := range fields {
+		for _, addr := range f.addrs {
+			if (f.name == "Announce" || f.name == "AppendAnnounce") && isAnnounceTemplate(addr) {
+				// checked once expanded, see ExpandAnnounce.
+				continue
+			}
+			if isIPCIDRFilter(addr) {
+				if f.name != "NoAnnounce" {
+					errs.add(fieldErrorf("Addresses."+f.name, "%q is a CIDR range, only NoAnnounce accepts ranges", addr))
+				} else if _, err := ipcidrNet(addr); err != nil {
+					errs.add(fieldErrorf("Addresses."+f.name, "%s", err))
+				}
+				continue
+			}
+			if _, err := ma.NewMultiaddr(addr); err != nil {
+				errs.add(fieldErrorf("Addresses."+f.name, "invalid multiaddr %q: %s", addr, err))
+			}
+		}
+	}
+	return errs.errOrNil()
+}
+
+var announceToken = regexp.MustCompile(`\{([A-Za-z0-9_-]+)\}`)
+
+func isAnnounceTemplate(addr string) bool {
+	return announceToken.MatchString(addr)
+}
+
+// AddAnnounce adds addr to AppendAnnounce when appendAddr is set, or to
+// Announce otherwise. addr must be a valid multiaddr or announce template.
+// Addresses already listed are not added twice.
+func (a *Addresses) AddAnnounce(addr string, appendAddr bool) error {
+	field, list := "Announce", &a.Announce
+	if appendAddr {
+		field, list = "AppendAnnounce", &a.AppendAnnounce
+	}
+	if !isAnnounceTemplate(addr) {
+		maddr, err := ma.NewMultiaddr(addr)
+		if err != nil {
+			return fieldErrorf("Addresses."+field, "invalid multiaddr %q: %s", addr, err)
+		}
+		addr = maddr.String()
+	}
+	*list = appendSingle(*list, []string{addr})
+	return nil
+}
+
+// loopbackFilters hold back loopback addresses, which defaultServerFilters
+// leave out.
+var loopbackFilters = []string{
+	"/ip4/127.0.0.0/ipcidr/8",
+	"/ip6/::1/ipcidr/128",
+}
+
+// SetPublicAddresses makes the node announce only the given public IPv4 and
+// IPv6 swarm addresses, e.g. "/ip4/1.2.3.4/tcp/4001", while still listening
+// on every interface. Either may be empty to announce a single family.
+// Private, loopback and unroutable ranges are added to NoAnnounce so nothing
+// else leaks. On error c is left unchanged.
+func (c *Config) SetPublicAddresses(v4, v6 string) error {
+	if v4 == "" && v6 == "" {
+		return fmt.Errorf("no public address given")
+	}
+	a := c.Addresses
+	a.NoAnnounce = appendSingle(a.NoAnnounce, append(append([]string{}, defaultServerFilters...), loopbackFilters...))
+
+	var announce []string
+	for _, public := range []struct {
+		addr     string
+		protocol int
+	}{
+		{v4, ma.P_IP4},
+		{v6, ma.P_IP6},
+	} {
+		if public.addr == "" {
+			continue
+		}
+		maddr, err := ma.NewMultiaddr(public.addr)
+		if err != nil {
+			return fmt.Errorf("invalid public address %q: %s", public.addr, err)
+		}
+		if first, _ := ma.SplitFirst(maddr); first == nil || first.Protocol().Code != public.protocol {
+			return fmt.Errorf("invalid public address %q: must start with /%s", public.addr, ma.ProtocolWithCode(public.protocol).Name)
+		}
+		if ok, err := a.ShouldAnnounce(maddr); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("invalid public address %q: held back by Addresses.NoAnnounce", public.addr)
+		}
+		announce = append(announce, maddr.String())
+	}
+	a.Announce = announce
+	c.Addresses = a
+	return nil
+}
+
+// ExpandAnnounce returns the Announce addresses with every template token,
+// such as the "{public}" in "/ip4/{public}/tcp/4001", replaced by the values
+// returned by resolve. A token resolving to several values expands the entry
+// into one address per value. Literal addresses are returned untouched.
+// Every expanded address must be a valid multiaddr.
+func (a Addresses) ExpandAnnounce(resolve func(token string) ([]string, error)) ([]string, error) {
+	return expandAnnounce("Announce", a.Announce, resolve)
+}
+
+// ExpandAppendAnnounce is ExpandAnnounce for AppendAnnounce.
+func (a Addresses) ExpandAppendAnnounce(resolve func(token string) ([]string, error)) ([]string, error) {
+	return expandAnnounce("AppendAnnounce", a.AppendAnnounce, resolve)
+}
+
+func expandAnnounce(field string, addrs []string, resolve func(token string) ([]string, error)) ([]string, error) {
+	resolved := make(map[string][]string)
+	seen := make(map[string]struct{})
+	var out []string
+	for _, addr := range addrs {
+		expanded := []string{addr}
+		for _, m := range announceToken.FindAllStringSubmatch(addr, -1) {
+			token := m[1]
+			values, ok := resolved[token]
+			if !ok {
+				var err error
+				if values, err = resolve(token); err != nil {
+					return nil, fieldErrorf("Addresses."+field, "cannot resolve {%s}: %s", token, err)
+				}
+				resolved[token] = values
+			}
+			next := make([]string, 0, len(expanded)*len(values))
+			for _, e := range expanded {
+				for _, v := range values {
+					next = append(next, strings.Replace(e, m[0], v, 1))
+				}
+			}
+			expanded = next
+		}
+
+		for _, e := range expanded {
+			if _, err := ma.NewMultiaddr(e); err != nil {
+				return nil, fieldErrorf("Addresses."+field, "%q expands to invalid multiaddr %q: %s", addr, e, err)
+			}
+			if _, ok := seen[e]; ok {
+				continue
+			}
+			seen[e] = struct{}{}
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// ShouldAnnounce reports whether addr passes the NoAnnounce filters, which
+// are either literal multiaddrs or CIDR ranges such as
+// "/ip4/192.168.0.0/ipcidr/16".
+func (a Addresses) ShouldAnnounce(addr ma.Multiaddr) (bool, error) {
+	ip := multiaddrIP(addr)
+	for _, f := range a.NoAnnounce {
+		if isIPCIDRFilter(f) {
+			n, err := ipcidrNet(f)
+			if err != nil {
+				return false, fieldErrorf("Addresses.NoAnnounce", "%s", err)
+			}
+			if ip != nil && n.Contains(ip) {
+				return false, nil
+			}
+			continue
+		}
+		maddr, err := ma.NewMultiaddr(f)
+		if err != nil {
+			return false, fieldErrorf("Addresses.NoAnnounce", "invalid multiaddr %q: %s", f, err)
+		}
+		if maddr.Equal(addr) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// multiaddrIP returns the leading IP address of maddr, if any.
+func multiaddrIP(maddr ma.Multiaddr) net.IP {
+	first, _ := ma.SplitFirst(maddr)
+	if first == nil {
+		return nil
+	}
+	switch first.Protocol().Code {
+	case ma.P_IP4, ma.P_IP6:
+		return net.IP(first.RawValue())
+	}
+	return nil
+}
+
+func isIPCIDRFilter(filter string) bool {
+	return strings.Contains(filter, "/ipcidr/")
+}
+
+// ipcidrNet parses an address filter such as "/ip4/10.0.0.0/ipcidr/8". The
+// ipcidr protocol is not known to our multiaddr version, so it is parsed by
+// hand.
+func ipcidrNet(filter string) (*net.IPNet, error) {
+	parts := strings.Split(filter, "/")
+	if len(parts) != 5 || parts[0] != "" || (parts[1] != "ip4" && parts[1] != "ip6") || parts[3] != "ipcidr" {
+		return nil, fmt.Errorf("invalid address filter %q: must be /ip4/<ip>/ipcidr/<bits> or /ip6/<ip>/ipcidr/<bits>", filter)
+	}
+	ip := net.ParseIP(parts[2])
+	if ip == nil || (parts[1] == "ip4") != (ip.To4() != nil) {
+		return nil, fmt.Errorf("invalid address filter %q: bad %s address %q", filter, parts[1], parts[2])
+	}
+	_, n, err := net.ParseCIDR(parts[2] + "/" + parts[4])
+	if err != nil {
+		return nil, fmt.Errorf("invalid address filter %q: %s", filter, err)
+	}
+	return n, nil
 }
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestExpandAnnounce(t *testing.T) {
+	resolve := func(token string) ([]string, error) {
+		switch token {
+		case "public":
+			return []string{"1.2.3.4", "5.6.7.8"}, nil
+		case "hostname":
+			return []string{"not an ip"}, nil
+		}
+		return nil, errors.New("unknown token")
+	}
+
+	a := Addresses{Announce: []string{"/ip4/9.9.9.9/tcp/4001"}}
+	out, err := a.ExpandAnnounce(resolve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, a.Announce) {
+		t.Fatalf("expected literal address to pass through, got %v", out)
+	}
+
+	a.Announce = []string{"/ip4/{public}/tcp/4001", "/ip4/9.9.9.9/tcp/4001"}
+	if err := a.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	out, err = a.ExpandAnnounce(resolve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/ip4/1.2.3.4/tcp/4001", "/ip4/5.6.7.8/tcp/4001", "/ip4/9.9.9.9/tcp/4001"}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("expected %v, got %v", expected, out)
+	}
+
+	a.Announce = []string{"/ip4/{private}/tcp/4001"}
+	if _, err := a.ExpandAnnounce(resolve); err == nil {
+		t.Fatal("expected unresolvable token to fail")
+	}
+	a.Announce = []string{"/ip4/{hostname}/tcp/4001"}
+	if _, err := a.ExpandAnnounce(resolve); err == nil {
+		t.Fatal("expected invalid expansion to fail")
+	}
+}
+
+func TestAddAnnounce(t *testing.T) {
+	var a Addresses
+	if err := a.AddAnnounce("/ip4/1.2.3.4/tcp/4001", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddAnnounce("/ip4/5.6.7.8/tcp/4001", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddAnnounce("/ip4/{public}/udp/4001/quic", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddAnnounce("/ip4/5.6.7.8/tcp/4001", true); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a.Announce, []string{"/ip4/1.2.3.4/tcp/4001"}) ||
+		!reflect.DeepEqual(a.AppendAnnounce, []string{"/ip4/5.6.7.8/tcp/4001", "/ip4/{public}/udp/4001/quic"}) {
+		t.Fatalf("unexpected announce addresses %v %v", a.Announce, a.AppendAnnounce)
+	}
+	if err := a.AddAnnounce("/ip4/1.2.3.4/tcp", true); err == nil {
+		t.Fatal("expected invalid address to be rejected")
+	}
+
+	if err := a.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	a.AppendAnnounce = append(a.AppendAnnounce, "/ip4/bogus")
+	if err := a.Validate(); err == nil {
+		t.Fatal("expected invalid AppendAnnounce entry to fail validation")
+	}
+}
+
+func TestShouldAnnounce(t *testing.T) {
+	a := Addresses{NoAnnounce: []string{"/ip4/192.168.0.0/ipcidr/16", "/ip4/1.2.3.4/tcp/4001"}}
+	for addr, expected := range map[string]bool{
+		"/ip4/192.168.1.20/tcp/4001":      false,
+		"/ip4/192.168.1.20/udp/4001/quic": false,
+		"/ip4/1.2.3.4/tcp/4001":           false,
+		"/ip4/1.2.3.4/tcp/4002":           true,
+		"/ip4/8.8.8.8/tcp/4001":           true,
+		"/ip6/::1/tcp/4001":               true,
+	} {
+		ok, err := a.ShouldAnnounce(ma.StringCast(addr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != expected {
+			t.Errorf("%s: expected %v, got %v", addr, expected, ok)
+		}
+	}
+
+	a.NoAnnounce = []string{"/ip4/192.168.0.0/ipcidr/40"}
+	if _, err := a.ShouldAnnounce(ma.StringCast("/ip4/8.8.8.8/tcp/4001")); err == nil {
+		t.Fatal("expected a bad filter to fail")
+	}
+}
+
+func TestValidateCIDRAddresses(t *testing.T) {
+	a := addressesConfig()
+	a.NoAnnounce = []string{"/ip4/192.168.0.0/ipcidr/16", "/ip6/fc00::/ipcidr/7", "/ip4/1.2.3.4/tcp/4001"}
+	if err := a.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	a.NoAnnounce = append(a.NoAnnounce, "/ip4/192.168.0.0/ipcidr/33")
+	a.Swarm = append(a.Swarm, "/ip4/10.0.0.0/ipcidr/8")
+	errs, ok := a.Validate().(ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected two validation errors, got %v", a.Validate())
+	}
+	if !strings.Contains(errs[0].Error(), "Addresses.Swarm") || !strings.Contains(errs[1].Error(), "Addresses.NoAnnounce") {
+		t.Fatalf("unexpected validation errors %v", errs)
+	}
+}
+
+func TestSetPublicAddresses(t *testing.T) {
+	c := NewDefault()
+	c.Addresses.NoAnnounce = []string{"/ip4/10.0.0.0/ipcidr/8"}
+	if err := c.SetPublicAddresses("/ip4/1.2.3.4/tcp/4001", "/ip6/2001:4860::1/udp/4001/quic"); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/ip4/1.2.3.4/tcp/4001", "/ip6/2001:4860::1/udp/4001/quic"}
+	if !reflect.DeepEqual(c.Addresses.Announce, expected) {
+		t.Fatalf("expected announce %v, got %v", expected, c.Addresses.Announce)
+	}
+	noAnnounce := c.Addresses.NoAnnounce
+	if len(noAnnounce) != len(defaultServerFilters)+len(loopbackFilters) || noAnnounce[0] != "/ip4/10.0.0.0/ipcidr/8" {
+		t.Fatalf("expected private ranges appended once to NoAnnounce, got %v", noAnnounce)
+	}
+	for _, f := range append(defaultServerFilters, loopbackFilters...) {
+		if !hasString(noAnnounce, f) {
+			t.Fatalf("expected %s in NoAnnounce", f)
+		}
+	}
+	if err := c.Addresses.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if w := c.Addresses.privateAnnounceWarnings(); len(w) != 0 {
+		t.Fatalf("expected no private announce warnings, got %v", w)
+	}
+
+	for _, bad := range [][2]string{
+		{"", ""},
+		{"/ip6/2001:4860::1/tcp/4001", ""},
+		{"/ip4/192.168.1.2/tcp/4001", ""},
+		{"/ip4/1.2.3.4/tcp/4001", "not a multiaddr"},
+	} {
+		before := append([]string{}, c.Addresses.Announce...)
+		if err := c.SetPublicAddresses(bad[0], bad[1]); err == nil {
+			t.Fatalf("expected %v to be rejected", bad)
+		}
+		if !reflect.DeepEqual(c.Addresses.Announce, before) {
+			t.Fatal("expected failed call to leave the config unchanged")
+		}
+	}
+}
@@ -1,5 +1,124 @@
 package config
 
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"sort"
+	"strings"
+)
+
 type API struct {
 	HTTPHeaders map[string][]string // HTTP headers to return with the API.
+
+	// Authorizations maps a name to the credentials and paths of an RPC
+	// auth scope. When empty, every request is allowed.
+	Authorizations map[string]*RPCAuthScope `json:",omitempty"`
+
+	// ReadTimeout, WriteTimeout and IdleTimeout bound the HTTP server
+	// connections, e.g. "30s". Zero disables a timeout, see ServerTimeouts.
+	ReadTimeout  string `json:",omitempty"`
+	WriteTimeout string `json:",omitempty"`
+	IdleTimeout  string `json:",omitempty"`
+}
+
+// Prefixes accepted in RPCAuthScope.AuthSecret.
+const (
+	AuthSecretBasicPrefix  = "basic:"
+	AuthSecretBearerPrefix = "bearer:"
+)
+
+// RPCAuthScope grants access to a set of API paths.
+type RPCAuthScope struct {
+	// AuthSecret is either "basic:user:password" or "bearer:token". A
+	// secret without a prefix is used as a bearer token.
+	AuthSecret string
+
+	// AllowedPaths lists the API path prefixes this scope may call, e.g.
+	// "/api/v1/id". "/api/v1" allows every command.
+	AllowedPaths []string
+}
+
+// credentials returns the Authorization header value expected for
+// the scope's secret, split into its scheme and credentials.
+func (s RPCAuthScope) credentials() (scheme, creds string) {
+	switch {
+	case strings.HasPrefix(s.AuthSecret, AuthSecretBasicPrefix):
+		return "Basic", strings.TrimPrefix(s.AuthSecret, AuthSecretBasicPrefix)
+	case strings.HasPrefix(s.AuthSecret, AuthSecretBearerPrefix):
+		return "Bearer", strings.TrimPrefix(s.AuthSecret, AuthSecretBearerPrefix)
+	default:
+		return "Bearer", s.AuthSecret
+	}
+}
+
+// validate checks that the scope's secret holds usable credentials.
+func (s RPCAuthScope) validate(name string) error {
+	path := "API.Authorizations." + name + ".AuthSecret"
+	scheme, creds := s.credentials()
+	switch {
+	case creds == "":
+		return fieldErrorf(path, "credentials must not be empty")
+	case scheme == "Basic" && strings.IndexByte(creds, ':') <= 0:
+		return fieldErrorf(path, "basic secret must be a user:password pair")
+	}
+	return nil
+}
+
+// validateAuthorizations checks the secret of every RPC auth scope.
+func (a API) validateAuthorizations() error {
+	var errs ValidationErrors
+	names := make([]string, 0, len(a.Authorizations))
+	for name := range a.Authorizations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if scope := a.Authorizations[name]; scope != nil {
+			errs.add(scope.validate(name))
+		}
+	}
+	return errs.errOrNil()
+}
+
+// allows reports whether path is one of the allowed paths or below one.
+func (s RPCAuthScope) allows(path string) bool {
+	for _, allowed := range s.AllowedPaths {
+		allowed = strings.TrimSuffix(allowed, "/")
+		if path == allowed || strings.HasPrefix(path, allowed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize reports whether a request with the given Authorization header
+// may call path. The auth scheme is matched case insensitively and scopes
+// with empty credentials never match.
+func (a API) Authorize(header string, path string) bool {
+	if len(a.Authorizations) == 0 {
+		return true
+	}
+	scheme, rest := header, ""
+	if i := strings.IndexByte(header, ' '); i >= 0 {
+		scheme, rest = header[:i], strings.TrimSpace(header[i+1:])
+	}
+	if rest == "" {
+		return false
+	}
+	for _, scope := range a.Authorizations {
+		if scope == nil {
+			continue
+		}
+		expectedScheme, creds := scope.credentials()
+		if creds == "" || !strings.EqualFold(scheme, expectedScheme) {
+			continue
+		}
+		if expectedScheme == "Basic" {
+			creds = base64.StdEncoding.EncodeToString([]byte(creds))
+		}
+		if subtle.ConstantTimeCompare([]byte(rest), []byte(creds)) == 1 && scope.allows(path) {
+			return true
+		}
+	}
+	return false
 }
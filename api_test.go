@@ -0,0 +1,140 @@
+package config
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIAuthorize(t *testing.T) {
+	var api API
+	if !api.Authorize("", "/api/v1/id") {
+		t.Fatal("expected requests to be allowed without authorizations")
+	}
+
+	api.Authorizations = map[string]*RPCAuthScope{
+		"proxy": {AuthSecret: "bearer:s3cret", AllowedPaths: []string{"/api/v1/id", "/api/v1/cat/"}},
+		"admin": {AuthSecret: "basic:admin:hunter2", AllowedPaths: []string{"/api/v1"}},
+		"plain": {AuthSecret: "token", AllowedPaths: []string{"/api/v1/version"}},
+	}
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:hunter2"))
+	for _, tc := range []struct {
+		header string
+		path   string
+		ok     bool
+	}{
+		{"Bearer s3cret", "/api/v1/id", true},
+		{"bearer s3cret", "/api/v1/cat/foo", true},
+		{"Bearer s3cret", "/api/v1/idx", false},
+		{"Bearer s3cret", "/api/v1/config", false},
+		{"Bearer wrong", "/api/v1/id", false},
+		{basic, "/api/v1/config", true},
+		{"Bearer token", "/api/v1/version", true},
+		{"Basic token", "/api/v1/version", false},
+		{"", "/api/v1/id", false},
+	} {
+		if api.Authorize(tc.header, tc.path) != tc.ok {
+			t.Errorf("Authorize(%q, %q): expected %v", tc.header, tc.path, tc.ok)
+		}
+	}
+}
+
+func TestAPIAuthorizeEmptyCredentials(t *testing.T) {
+	api := API{Authorizations: map[string]*RPCAuthScope{
+		"basic":  {AuthSecret: "basic:", AllowedPaths: []string{"/api/v1"}},
+		"bearer": {AuthSecret: "bearer:", AllowedPaths: []string{"/api/v1"}},
+	}}
+	for _, header := range []string{"Basic", "Bearer", "Basic ", "Bearer "} {
+		if api.Authorize(header, "/api/v1/id") {
+			t.Errorf("expected %q to be rejected", header)
+		}
+	}
+
+	errs, ok := api.Validate().(ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected two validation errors, got %v", api.Validate())
+	}
+	api.Authorizations = map[string]*RPCAuthScope{"admin": {AuthSecret: "basic:admin"}}
+	errs, ok = api.Validate().(ValidationErrors)
+	if !ok || len(errs) != 1 || errs[0].(FieldError).Path != "API.Authorizations.admin.AuthSecret" {
+		t.Fatalf("expected basic secret without a password to be rejected, got %v", api.Validate())
+	}
+}
+
+func TestAPIAuthorizationsRedacted(t *testing.T) {
+	c := new(Config)
+	c.API.Authorizations = map[string]*RPCAuthScope{
+		"proxy": {AuthSecret: "bearer:s3cret", AllowedPaths: []string{"/api/v1"}},
+	}
+	out, err := c.MarshalRedacted()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "s3cret") {
+		t.Fatalf("expected auth secret to be redacted, got %s", out)
+	}
+}
+
+func TestAPICORS(t *testing.T) {
+	var api API
+	api.HTTPHeaders = map[string][]string{"access-control-allow-origin": {"http://old.example"}}
+	api.SetCORS([]string{"http://localhost:3000"}, []string{"GET", "POST"}, []string{"X-Requested-With"})
+
+	if len(api.HTTPHeaders) != 3 {
+		t.Fatalf("expected three headers, got %v", api.HTTPHeaders)
+	}
+	for _, key := range []string{CORSAllowOriginHeader, CORSAllowMethodsHeader, CORSAllowHeadersHeader} {
+		if _, ok := api.HTTPHeaders[key]; !ok {
+			t.Fatalf("expected canonical header %s, got %v", key, api.HTTPHeaders)
+		}
+	}
+
+	origins, methods, headers := api.CORS()
+	if len(origins) != 1 || origins[0] != "http://localhost:3000" ||
+		len(methods) != 2 || methods[1] != "POST" ||
+		len(headers) != 1 || headers[0] != "X-Requested-With" {
+		t.Fatalf("unexpected CORS config %v %v %v", origins, methods, headers)
+	}
+
+	api.SetCORS([]string{"*"}, nil, nil)
+	origins, methods, headers = api.CORS()
+	if len(origins) != 1 || methods != nil || headers != nil {
+		t.Fatalf("expected only origins to remain, got %v %v %v", origins, methods, headers)
+	}
+}
+
+func TestServerTimeouts(t *testing.T) {
+	c := NewDefault()
+	for name, timeouts := range map[string]func() (time.Duration, time.Duration, time.Duration, error){
+		"API":     c.API.ServerTimeouts,
+		"Gateway": c.Gateway.ServerTimeouts,
+		"unset":   API{}.ServerTimeouts,
+	} {
+		read, write, idle, err := timeouts()
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if read != 30*time.Second || write != 0 || idle != 2*time.Minute {
+			t.Errorf("%s: unexpected default timeouts %s, %s, %s", name, read, write, idle)
+		}
+	}
+
+	a := API{ReadTimeout: "1m", WriteTimeout: "0", IdleTimeout: "0s"}
+	read, write, idle, err := a.ServerTimeouts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read != time.Minute || write != 0 || idle != 0 {
+		t.Fatalf("unexpected timeouts %s, %s, %s", read, write, idle)
+	}
+
+	a.WriteTimeout = "forever"
+	if err := a.Validate(); err == nil || !strings.Contains(err.Error(), "API.WriteTimeout") {
+		t.Fatalf("expected invalid write timeout to be rejected, got %v", err)
+	}
+	g := Gateway{IdleTimeout: "-1s"}
+	if err := g.Validate(); err == nil || !strings.Contains(err.Error(), "Gateway.IdleTimeout") {
+		t.Fatalf("expected negative idle timeout to be rejected, got %v", err)
+	}
+}
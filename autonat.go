@@ -79,3 +79,24 @@ type AutoNATThrottleConfig struct {
 	// When unset, this defaults to 1 minute.
 	Interval Duration `json:",omitempty"`
 }
+
+// Validate checks the AutoNAT service mode and throttle limits. The service
+// mode and throttle interval are already parsed when the config is decoded,
+// this catches values set programmatically.
+func (c AutoNATConfig) Validate() error {
+	var errs ValidationErrors
+	switch c.ServiceMode {
+	case AutoNATServiceUnset, AutoNATServiceEnabled, AutoNATServiceDisabled:
+	default:
+		errs.add(fieldErrorf("AutoNAT.ServiceMode", "unknown autonat mode: %d", c.ServiceMode))
+	}
+	if t := c.Throttle; t != nil {
+		if t.GlobalLimit < 0 || t.PeerLimit < 0 {
+			errs.add(fieldErrorf("AutoNAT.Throttle", "limits must not be negative"))
+		}
+		if t.Interval < 0 {
+			errs.add(fieldErrorf("AutoNAT.Throttle.Interval", "must not be negative, got %s", t.Interval))
+		}
+	}
+	return errs.errOrNil()
+}
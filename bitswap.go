@@ -0,0 +1,69 @@
+package config
+
+import "time"
+
+// Modes reported by Bitswap.Mode.
+const (
+	BitswapModeFull       = "full"
+	BitswapModeServerOnly = "server-only"
+	BitswapModeClientOnly = "client-only"
+	BitswapModeDisabled   = "disabled"
+)
+
+// Bitswap selects the sides of the bitswap protocol the node runs. Low level
+// engine tuning lives in Internal.Bitswap.
+type Bitswap struct {
+	// ServerEnabled serves blocks to peers asking for them. Defaults to on.
+	ServerEnabled Flag `json:",omitempty"`
+
+	// ClientEnabled fetches missing blocks from peers. Defaults to on.
+	ClientEnabled Flag `json:",omitempty"`
+
+	// ProviderSearchDelay is how long the client waits for connected peers
+	// to answer before searching the routing system for providers, e.g.
+	// "1s".
+	ProviderSearchDelay string `json:",omitempty"`
+}
+
+// Mode returns BitswapModeFull, BitswapModeServerOnly, BitswapModeClientOnly
+// or BitswapModeDisabled depending on the enabled sides.
+func (b Bitswap) Mode() string {
+	server := b.ServerEnabled.WithDefault(true)
+	client := b.ClientEnabled.WithDefault(true)
+	switch {
+	case server && client:
+		return BitswapModeFull
+	case server:
+		return BitswapModeServerOnly
+	case client:
+		return BitswapModeClientOnly
+	default:
+		return BitswapModeDisabled
+	}
+}
+
+// Validate checks the flags and the provider search delay.
+func (b Bitswap) Validate() error {
+	var errs ValidationErrors
+	for _, f := range []struct {
+		name string
+		flag Flag
+	}{
+		{"ServerEnabled", b.ServerEnabled},
+		{"ClientEnabled", b.ClientEnabled},
+	} {
+		switch f.flag {
+		case Default, True, False:
+		default:
+			errs.add(fieldErrorf("Bitswap."+f.name, "invalid flag value %d", f.flag))
+		}
+	}
+	if b.ProviderSearchDelay != "" {
+		if d, err := time.ParseDuration(b.ProviderSearchDelay); err != nil {
+			errs.add(fieldErrorf("Bitswap.ProviderSearchDelay", "%s", err))
+		} else if d < 0 {
+			errs.add(fieldErrorf("Bitswap.ProviderSearchDelay", "must not be negative, got %s", b.ProviderSearchDelay))
+		}
+	}
+	return errs.errOrNil()
+}
@@ -0,0 +1,38 @@
+package config
+
+import "testing"
+
+func TestBitswapMode(t *testing.T) {
+	for _, tc := range []struct {
+		server, client Flag
+		mode           string
+	}{
+		{Default, Default, BitswapModeFull},
+		{True, True, BitswapModeFull},
+		{True, False, BitswapModeServerOnly},
+		{Default, False, BitswapModeServerOnly},
+		{False, True, BitswapModeClientOnly},
+		{False, Default, BitswapModeClientOnly},
+		{False, False, BitswapModeDisabled},
+	} {
+		b := Bitswap{ServerEnabled: tc.server, ClientEnabled: tc.client}
+		if mode := b.Mode(); mode != tc.mode {
+			t.Errorf("server %s, client %s: expected %q, got %q", tc.server, tc.client, tc.mode, mode)
+		}
+	}
+
+	if mode := DefaultBitswap().Mode(); mode != BitswapModeFull {
+		t.Fatalf("expected both sides to be enabled by default, got %q", mode)
+	}
+
+	b := Bitswap{ProviderSearchDelay: "1s"}
+	if err := b.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, delay := range []string{"soon", "-1s"} {
+		b.ProviderSearchDelay = delay
+		if err := b.Validate(); err == nil {
+			t.Errorf("expected delay %q to be rejected", delay)
+		}
+	}
+}
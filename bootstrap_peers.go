@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	peer "github.com/libp2p/go-libp2p-core/peer"
 	ma "github.com/multiformats/go-multiaddr"
@@ -74,19 +75,67 @@ Please report it to https://github.com/TRON-US/go-btfs/issues.`, err)
 	return ps, nil
 }
 
+// BootstrapPeersForTransport returns the default bootstrap peers, keeping
+// only the addresses that use the given transport ("tcp" or "quic"). Peers
+// without a matching address are left out.
+func BootstrapPeersForTransport(transport string) ([]peer.AddrInfo, error) {
+	return filterBootstrapTransport(DefaultBootstrapAddresses, transport)
+}
+
+// filterBootstrapTransport parses the bootstrap addresses in bootstrap that
+// use the given transport.
+func filterBootstrapTransport(bootstrap []string, transport string) ([]peer.AddrInfo, error) {
+	var code int
+	switch strings.ToLower(transport) {
+	case "tcp":
+		code = ma.P_TCP
+	case "quic":
+		code = ma.P_QUIC
+	default:
+		return nil, fmt.Errorf("unknown bootstrap transport %q", transport)
+	}
+
+	var addrs []string
+	for _, addr := range bootstrap {
+		maddr, err := ma.NewMultiaddr(addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bootstrap peer %q: %s", addr, err)
+		}
+		if _, err := maddr.ValueForProtocol(code); err == nil {
+			addrs = append(addrs, addr)
+		}
+	}
+	return ParseBootstrapPeers(addrs)
+}
+
 func (c *Config) SetBootstrapPeers(bps []peer.AddrInfo) {
 	c.Bootstrap = BootstrapPeerStrings(bps)
 }
 
-// ParseBootstrapPeer parses a bootstrap list into a list of AddrInfos.
+// SetBootstrapToDefault replaces the bootstrap list with a copy of
+// DefaultBootstrapAddresses, keeping their order.
+func (c *Config) SetBootstrapToDefault() error {
+	if _, err := DefaultBootstrapPeers(); err != nil {
+		return err
+	}
+	c.Bootstrap = append([]string{}, DefaultBootstrapAddresses...)
+	return nil
+}
+
+// ParseBootstrapPeers parses a bootstrap list into a list of AddrInfos.
+// Addresses sharing a peer ID are grouped into a single AddrInfo. Errors name
+// the entry that failed to parse.
 func ParseBootstrapPeers(addrs []string) ([]peer.AddrInfo, error) {
 	maddrs := make([]ma.Multiaddr, len(addrs))
 	for i, addr := range addrs {
-		var err error
-		maddrs[i], err = ma.NewMultiaddr(addr)
+		maddr, err := ma.NewMultiaddr(addr)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid bootstrap address %d (%q): %s", i, addr, err)
 		}
+		if _, err := peer.AddrInfoFromP2pAddr(maddr); err != nil {
+			return nil, fmt.Errorf("invalid bootstrap address %d (%q): %w", i, addr, err)
+		}
+		maddrs[i] = maddr
 	}
 	return peer.AddrInfosFromP2pAddrs(maddrs...)
 }
@@ -107,3 +156,66 @@ func BootstrapPeerStrings(bps []peer.AddrInfo) []string {
 	}
 	return bpss
 }
+
+// AddBootstrapPeers validates and appends the given peer addresses to the
+// bootstrap list, skipping entries already present. Every address must
+// contain a /p2p component. It returns the number of entries added.
+func (c *Config) AddBootstrapPeers(addrs []string) (int, error) {
+	existing := make(map[string]struct{}, len(c.Bootstrap))
+	for _, addr := range c.Bootstrap {
+		// compare in canonical form, e.g. /ipfs/<id> as /p2p/<id>.
+		if maddr, err := ma.NewMultiaddr(addr); err == nil {
+			existing[maddr.String()] = struct{}{}
+		}
+	}
+
+	toAdd := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		maddr, err := ma.NewMultiaddr(addr)
+		if err != nil {
+			return 0, fmt.Errorf("Bootstrap: invalid address %q: %s", addr, err)
+		}
+		if _, err := maddr.ValueForProtocol(ma.P_P2P); err != nil {
+			return 0, fmt.Errorf("Bootstrap: %w %q: missing /p2p component", ErrInvalidPeerAddr, addr)
+		}
+		s := maddr.String()
+		if _, ok := existing[s]; ok {
+			continue
+		}
+		existing[s] = struct{}{}
+		toAdd = append(toAdd, s)
+	}
+
+	bootstrap := make([]string, 0, len(c.Bootstrap)+len(toAdd))
+	bootstrap = append(bootstrap, c.Bootstrap...)
+	c.Bootstrap = append(bootstrap, toAdd...)
+	return len(toAdd), nil
+}
+
+// RemoveBootstrapPeers removes every bootstrap entry that points at one of the
+// given peers. It returns the number of entries removed.
+func (c *Config) RemoveBootstrapPeers(peerIDs []peer.ID) (int, error) {
+	remove := make(map[peer.ID]struct{}, len(peerIDs))
+	for _, id := range peerIDs {
+		remove[id] = struct{}{}
+	}
+
+	kept := make([]string, 0, len(c.Bootstrap))
+	for _, addr := range c.Bootstrap {
+		maddr, err := ma.NewMultiaddr(addr)
+		if err != nil {
+			return 0, fmt.Errorf("Bootstrap: invalid address %q: %s", addr, err)
+		}
+		ai, err := peer.AddrInfoFromP2pAddr(maddr)
+		if err == nil {
+			if _, ok := remove[ai.ID]; ok {
+				continue
+			}
+		}
+		kept = append(kept, addr)
+	}
+
+	removed := len(c.Bootstrap) - len(kept)
+	c.Bootstrap = kept
+	return removed, nil
+}
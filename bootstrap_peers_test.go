@@ -1,8 +1,13 @@
 package config
 
 import (
+	"errors"
 	"sort"
+	"strings"
 	"testing"
+
+	peer "github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
 )
 
 func TestBoostrapPeerStrings(t *testing.T) {
@@ -40,3 +45,125 @@ func TestTestnetBoostrapPeerStrings(t *testing.T) {
 		}
 	}
 }
+
+func TestAddRemoveBootstrapPeers(t *testing.T) {
+	c := new(Config)
+	c.Bootstrap = []string{DefaultBootstrapAddresses[0]}
+
+	n, err := c.AddBootstrapPeers(DefaultBootstrapAddresses[:3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || len(c.Bootstrap) != 3 {
+		t.Fatalf("expected 2 peers added and 3 total, got %d and %d", n, len(c.Bootstrap))
+	}
+
+	if _, err := c.AddBootstrapPeers([]string{"/ip4/1.2.3.4/tcp/4001"}); !errors.Is(err, ErrInvalidPeerAddr) || !strings.HasPrefix(err.Error(), "Bootstrap: ") {
+		t.Fatalf("expected address without /p2p to be rejected, got %v", err)
+	}
+
+	parsed, err := ParseBootstrapPeers(DefaultBootstrapAddresses[1:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err = c.RemoveBootstrapPeers([]peer.ID{parsed[0].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || len(c.Bootstrap) != 2 {
+		t.Fatalf("expected 1 peer removed and 2 left, got %d and %d", n, len(c.Bootstrap))
+	}
+	for _, addr := range c.Bootstrap {
+		if addr == DefaultBootstrapAddresses[1] {
+			t.Fatal("removed peer still in bootstrap list")
+		}
+	}
+}
+
+func TestAddBootstrapPeersLegacyForm(t *testing.T) {
+	legacy := strings.Replace(DefaultBootstrapAddresses[0], "/p2p/", "/ipfs/", 1)
+	c := new(Config)
+	c.Bootstrap = []string{legacy, "not a multiaddr"}
+
+	n, err := c.AddBootstrapPeers(DefaultBootstrapAddresses[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 || !sameStrings(c.Bootstrap, []string{legacy, "not a multiaddr"}) {
+		t.Fatalf("expected the /ipfs/ entry to count as present, got %d added and %v", n, c.Bootstrap)
+	}
+}
+
+func TestBootstrapPeersForTransport(t *testing.T) {
+	for _, transport := range []string{"quic", "tcp"} {
+		peers, err := BootstrapPeersForTransport(transport)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code := ma.P_TCP
+		if transport == "quic" {
+			code = ma.P_QUIC
+		}
+		seen := make(map[peer.ID]struct{})
+		for _, pi := range peers {
+			if len(pi.Addrs) == 0 {
+				t.Fatalf("%s: peer %s has no addresses", transport, pi.ID)
+			}
+			if _, ok := seen[pi.ID]; ok {
+				t.Fatalf("%s: duplicate peer %s", transport, pi.ID)
+			}
+			seen[pi.ID] = struct{}{}
+			for _, addr := range pi.Addrs {
+				if _, err := addr.ValueForProtocol(code); err != nil {
+					t.Fatalf("%s: unexpected address %s", transport, addr)
+				}
+			}
+		}
+	}
+
+	tcp, _ := BootstrapPeersForTransport("TCP")
+	if len(tcp) != len(DefaultBootstrapAddresses) {
+		t.Fatalf("expected %d tcp peers, got %d", len(DefaultBootstrapAddresses), len(tcp))
+	}
+	if _, err := BootstrapPeersForTransport("carrier-pigeon"); err == nil {
+		t.Fatal("expected unknown transport to be rejected")
+	}
+
+	// the default list has no QUIC addresses, check the filter on a mixed
+	// one.
+	id := "QmWJWGxKKaqZUW4xga2BCzT5FBtYDL8Cc5Q5jywd6xPt1g"
+	tcpAddr := "/ip4/18.237.54.123/tcp/4001/p2p/" + id
+	quicAddr := "/ip4/18.237.54.123/udp/4001/quic/p2p/" + id
+	quicOnly := "/ip4/18.237.54.124/udp/4001/quic/p2p/QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN"
+	for transport, expected := range map[string][]string{
+		"tcp":  {tcpAddr},
+		"quic": {quicAddr, quicOnly},
+	} {
+		peers, err := filterBootstrapTransport([]string{tcpAddr, quicAddr, quicOnly}, transport)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := BootstrapPeerStrings(peers); !sameStrings(got, expected) {
+			t.Errorf("%s: expected %v, got %v", transport, expected, got)
+		}
+	}
+}
+
+func TestParseBootstrapPeersGroupsByPeer(t *testing.T) {
+	id := "QmWJWGxKKaqZUW4xga2BCzT5FBtYDL8Cc5Q5jywd6xPt1g"
+	peers, err := ParseBootstrapPeers([]string{
+		"/ip4/18.237.54.123/tcp/4001/p2p/" + id,
+		"/ip4/18.237.54.123/udp/4001/quic/p2p/" + id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 1 || peers[0].ID.Pretty() != id || len(peers[0].Addrs) != 2 {
+		t.Fatalf("expected one peer with two addresses, got %v", peers)
+	}
+
+	_, err = ParseBootstrapPeers([]string{DefaultBootstrapAddresses[0], "/ip4/1.2.3.4/tcp/4001"})
+	if err == nil || !strings.Contains(err.Error(), "/ip4/1.2.3.4/tcp/4001") {
+		t.Fatalf("expected error naming the bad entry, got %v", err)
+	}
+}
@@ -14,7 +14,14 @@ import (
 
 // Config is used to load ipfs config files.
 type Config struct {
-	Identity  Identity  // local node's peer identity
+	Version  int      // config file format version, see CurrentConfigVersion
+	Identity Identity // local node's peer identity
+
+	// Identities optionally stores alternate identities by alias, the one
+	// named by ActiveIdentity mirrors Identity. See UseIdentity.
+	Identities     map[string]Identity `json:",omitempty"`
+	ActiveIdentity string              `json:",omitempty"`
+
 	Datastore Datastore // local node's storage
 	Addresses Addresses // local node's addresses
 	Mounts    Mounts    // local node's mount points
@@ -25,9 +32,15 @@ type Config struct {
 	Gateway   Gateway   // local node's gateway server options
 	API       API       // local node's API settings
 	Swarm     SwarmConfig
+	Bitswap   Bitswap
 	AutoNAT   AutoNATConfig
 	Pubsub    PubsubConfig
 	Peering   Peering
+	Pinning   Pinning
+	Import    Import
+	Logging   Logging
+	DNS       DNS
+	Internal  Internal
 
 	Services Services // External service domains and info
 
@@ -36,6 +49,10 @@ type Config struct {
 	Experimental Experiments
 	UI           UI
 	Plugins      Plugins
+
+	// Extra holds top level keys unknown to this version, see UnknownFields,
+	// and metadata keys, see MetadataPrefix.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 const (
@@ -94,6 +111,18 @@ func Marshal(value interface{}) ([]byte, error) {
 	return json.MarshalIndent(value, "", "  ")
 }
 
+// MarshalStable marshals the config as indented JSON that is byte for byte
+// identical across calls for the same config, making it suitable for files
+// that get diffed or checked in.
+//
+// encoding/json already writes map keys (including the nested interface{}
+// maps in Datastore.Spec) in sorted order and struct fields in declaration
+// order, so this is Marshal; it exists to make that guarantee explicit for
+// callers and is covered by tests.
+func (c *Config) MarshalStable() ([]byte, error) {
+	return Marshal(c)
+}
+
 func FromMap(v map[string]interface{}) (*Config, error) {
 	buf := new(bytes.Buffer)
 	if err := json.NewEncoder(buf).Encode(v); err != nil {
@@ -119,6 +148,10 @@ func ToMap(conf *Config) (map[string]interface{}, error) {
 }
 
 // Clone copies the config. Use when updating.
+//
+// The copy is made by round-tripping through JSON, so every nested map and
+// slice (including the interface{} maps in Datastore.Spec) is independent of
+// the original.
 func (c *Config) Clone() (*Config, error) {
 	var newConfig Config
 	var buf bytes.Buffer
@@ -133,3 +166,108 @@ func (c *Config) Clone() (*Config, error) {
 
 	return &newConfig, nil
 }
+
+// ToMap converts the config into a generic map, keyed by JSON field names.
+func (c *Config) ToMap() (map[string]interface{}, error) {
+	return ToMap(c)
+}
+
+// GetKey returns the value at the given dot separated path, e.g.
+// "Swarm.ConnMgr.HighWater". Path segments are matched case insensitively.
+func (c *Config) GetKey(path string) (interface{}, error) {
+	m, err := c.ToMap()
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor interface{} = m
+	var walked []string
+	for _, part := range strings.Split(path, ".") {
+		mcursor, ok := cursor.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s is not a map", strings.Join(walked, "."))
+		}
+		key, ok := mapKey(mcursor, part)
+		if !ok {
+			walked = append(walked, part)
+			return nil, fmt.Errorf("%s key has no attributes", strings.Join(walked, "."))
+		}
+		walked = append(walked, key)
+		cursor = mcursor[key]
+	}
+	return cursor, nil
+}
+
+// SetKey sets the value at the given dot separated path, creating
+// intermediate maps as needed, and reloads the config from the result.
+func (c *Config) SetKey(path string, value interface{}) error {
+	m, err := c.ToMap()
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(path, ".")
+	cursor := m
+	var walked []string
+	for i, part := range parts {
+		key, ok := mapKey(cursor, part)
+		if !ok {
+			key = part
+		}
+		walked = append(walked, key)
+		if i == len(parts)-1 {
+			cursor[key] = value
+			break
+		}
+
+		next, ok := cursor[key]
+		if !ok || next == nil {
+			nm := map[string]interface{}{}
+			cursor[key] = nm
+			cursor = nm
+			continue
+		}
+		nm, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s is not a map", strings.Join(walked, "."))
+		}
+		cursor = nm
+	}
+
+	conf, err := FromMap(m)
+	if err != nil {
+		return err
+	}
+	*c = *conf
+	return nil
+}
+
+// mapKey finds key in m, falling back to a case insensitive match.
+func mapKey(m map[string]interface{}, key string) (string, bool) {
+	if _, ok := m[key]; ok {
+		return key, true
+	}
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// Merge applies a partial JSON config on top of c. Fields present in the
+// overlay replace the current values, absent fields are left untouched.
+// Slices (e.g. Bootstrap, Addresses.Swarm) are replaced as a whole, while
+// maps (e.g. API.HTTPHeaders) are merged key by key. On error c is left
+// unchanged.
+func (c *Config) Merge(overlay []byte) error {
+	merged, err := c.Clone()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(overlay, merged); err != nil {
+		return fmt.Errorf("failure to decode config overlay: %s", err)
+	}
+	*c = *merged
+	return nil
+}
@@ -1,6 +1,10 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -27,3 +31,637 @@ func TestClone(t *testing.T) {
 		t.Fatal("HTTP headers not preserved")
 	}
 }
+
+func TestCloneDeep(t *testing.T) {
+	c := new(Config)
+	c.Bootstrap = []string{"/ip4/1.2.3.4/tcp/4001"}
+	c.Gateway.HTTPHeaders = map[string][]string{"foo": {"bar"}}
+	c.Addresses.Swarm = []string{"/ip4/0.0.0.0/tcp/4001"}
+	c.Datastore.Spec = flatfsSpec()
+
+	newCfg, err := c.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newCfg.Bootstrap[0] = "changed"
+	newCfg.Gateway.HTTPHeaders["foo"][0] = "changed"
+	newCfg.Addresses.Swarm[0] = "changed"
+	mounts := newCfg.Datastore.Spec["mounts"].([]interface{})
+	child := mounts[0].(map[string]interface{})["child"].(map[string]interface{})
+	child["path"] = "changed"
+	newCfg.Datastore.Spec["type"] = "changed"
+
+	if c.Bootstrap[0] == "changed" {
+		t.Fatal("bootstrap list shared with clone")
+	}
+	if c.Gateway.HTTPHeaders["foo"][0] == "changed" {
+		t.Fatal("gateway headers shared with clone")
+	}
+	if c.Addresses.Swarm[0] == "changed" {
+		t.Fatal("swarm addresses shared with clone")
+	}
+	if c.Datastore.Spec["type"] != "mount" {
+		t.Fatal("datastore spec shared with clone")
+	}
+	origMounts := c.Datastore.Spec["mounts"].([]interface{})
+	origChild := origMounts[0].(map[string]interface{})["child"].(map[string]interface{})
+	if origChild["path"] != "blocks" {
+		t.Fatal("nested datastore spec shared with clone")
+	}
+}
+
+func TestValidateAddresses(t *testing.T) {
+	c := new(Config)
+	c.Addresses = addressesConfig()
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Addresses.Swarm = append(c.Addresses.Swarm, "/ip4/0.0.0.0/tcp")
+	c.Addresses.API = Strings{"/ip4/127.0.0.1/tcp/foo"}
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("expected invalid addresses to be rejected")
+	}
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected two validation errors, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := new(Config)
+	c.Addresses = addressesConfig()
+	c.Datastore = DefaultDatastoreConfig()
+	c.Swarm.ConnMgr = ConnMgr{Type: "basic", LowWater: 600, HighWater: 900, GracePeriod: "20s"}
+	c.Reprovider = Reprovider{Interval: "12h", Strategy: "all"}
+	c.Routing.Type = "dht"
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Datastore.StorageMax = "10 gigs"
+	c.Swarm.ConnMgr.LowWater = 1000
+	c.Swarm.ConnMgr.GracePeriod = "twenty"
+	c.Reprovider.Interval = "often"
+	c.Reprovider.Strategy = "alll"
+	c.Ipns.ResolveCacheSize = -1
+	c.Routing.Type = "kad"
+	c.Pubsub.Router = "meshsub"
+	c.Pubsub.SeenMessagesTTL = "forever"
+	err := c.Validate()
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 9 {
+		t.Fatalf("expected nine validation errors, got %v", err)
+	}
+}
+
+func TestGetSetKey(t *testing.T) {
+	c := new(Config)
+	c.Swarm.ConnMgr.HighWater = 900
+
+	v, err := c.GetKey("Swarm.ConnMgr.HighWater")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != float64(900) {
+		t.Fatalf("expected 900, got %v", v)
+	}
+
+	if err := c.SetKey("swarm.connmgr.highwater", 100); err != nil {
+		t.Fatal(err)
+	}
+	if c.Swarm.ConnMgr.HighWater != 100 {
+		t.Fatalf("expected 100, got %d", c.Swarm.ConnMgr.HighWater)
+	}
+
+	if err := c.SetKey("Gateway.PublicGateways.localhost", map[string]interface{}{"Paths": []string{"/btfs"}}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Gateway.PublicGateways["localhost"] == nil {
+		t.Fatal("expected intermediate maps to be created")
+	}
+
+	if err := c.SetKey("Routing.Type.Foo", "bar"); err == nil {
+		t.Fatal("expected setting a key below a string to fail")
+	}
+	if _, err := c.GetKey("Routing.Nope"); err == nil {
+		t.Fatal("expected missing key to fail")
+	}
+}
+
+func TestMarshalRedacted(t *testing.T) {
+	c := new(Config)
+	c.Identity.PeerID = "faketest"
+	c.Identity.PrivKey = "c2VjcmV0LWtleQ=="
+	c.Identity.Mnemonic = "abandon abandon about"
+
+	out, err := c.MarshalRedacted()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), c.Identity.PrivKey) {
+		t.Fatal("private key leaked into redacted output")
+	}
+	if strings.Contains(string(out), c.Identity.Mnemonic) {
+		t.Fatal("mnemonic leaked into redacted output")
+	}
+	if !strings.Contains(string(out), c.Identity.PeerID) {
+		t.Fatal("expected peer id to be kept")
+	}
+	if c.Identity.PrivKey != "c2VjcmV0LWtleQ==" {
+		t.Fatal("redaction modified the original config")
+	}
+}
+
+func TestGoString(t *testing.T) {
+	c := new(Config)
+	c.Identity.PeerID = "faketest"
+	c.Identity.PrivKey = "c2VjcmV0LWtleQ=="
+
+	for _, out := range []string{fmt.Sprintf("%#v", c), fmt.Sprintf("%#v", *c)} {
+		if strings.Contains(out, c.Identity.PrivKey) {
+			t.Fatal("private key leaked into GoString output")
+		}
+		if !strings.Contains(out, RedactedValue) || !strings.Contains(out, c.Identity.PeerID) {
+			t.Fatalf("expected redacted config, got %s", out)
+		}
+	}
+}
+
+func TestPeering(t *testing.T) {
+	peers, err := ParseBootstrapPeers(DefaultBootstrapAddresses[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := new(Config)
+	c.AddPeeringPeer(peers[0])
+	c.AddPeeringPeer(peers[1])
+	c.AddPeeringPeer(peers[0])
+	if len(c.Peering.Peers) != 2 {
+		t.Fatalf("expected two peering peers, got %d", len(c.Peering.Peers))
+	}
+
+	buf, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded Config
+	if err := json.Unmarshal(buf, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range loaded.Peering.Peers {
+		if p.ID != peers[i].ID || len(p.Addrs) != 1 || !p.Addrs[0].Equal(peers[i].Addrs[0]) {
+			t.Fatalf("expected peer %v, got %v", peers[i], p)
+		}
+	}
+
+	if !loaded.RemovePeeringPeer(peers[0].ID) || loaded.RemovePeeringPeer(peers[0].ID) {
+		t.Fatal("expected peer to be removed exactly once")
+	}
+	if len(loaded.Peering.Peers) != 1 || loaded.Peering.Peers[0].ID != peers[1].ID {
+		t.Fatalf("unexpected peering peers %v", loaded.Peering.Peers)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	c := new(Config)
+	c.Identity.PeerID = "faketest"
+	c.Routing.Type = "dht"
+	c.Swarm.ConnMgr.HighWater = 900
+	c.Bootstrap = []string{"a", "b"}
+	c.API.HTTPHeaders = map[string][]string{"foo": {"bar"}, "baz": {"qux"}}
+
+	overlay := []byte(`{
+		"Routing": {"Type": "dhtclient"},
+		"Bootstrap": ["c"],
+		"API": {"HTTPHeaders": {"foo": ["override"], "new": ["value"]}}
+	}`)
+	if err := c.Merge(overlay); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Routing.Type != "dhtclient" {
+		t.Fatalf("expected scalar override, got %s", c.Routing.Type)
+	}
+	if c.Identity.PeerID != "faketest" || c.Swarm.ConnMgr.HighWater != 900 {
+		t.Fatal("expected untouched fields to be preserved")
+	}
+	if len(c.Bootstrap) != 1 || c.Bootstrap[0] != "c" {
+		t.Fatalf("expected bootstrap list to be replaced, got %v", c.Bootstrap)
+	}
+	expected := map[string][]string{"foo": {"override"}, "baz": {"qux"}, "new": {"value"}}
+	if !reflect.DeepEqual(c.API.HTTPHeaders, expected) {
+		t.Fatalf("expected headers %v, got %v", expected, c.API.HTTPHeaders)
+	}
+
+	if err := c.Merge([]byte(`{"Routing": 1}`)); err == nil {
+		t.Fatal("expected bad overlay to fail")
+	}
+	if c.Routing.Type != "dhtclient" {
+		t.Fatal("expected failed merge to leave the config unchanged")
+	}
+}
+
+func TestReproviderValidate(t *testing.T) {
+	for _, r := range []Reprovider{
+		{Interval: "12h", Strategy: ReproviderStrategyAll},
+		{Interval: "0", Strategy: ReproviderStrategyPinned},
+		{Strategy: ReproviderStrategyRoots},
+	} {
+		if err := r.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := Reprovider{Interval: "12h", Strategy: "alll"}.Validate()
+	if err == nil || !strings.Contains(err.Error(), `"alll"`) {
+		t.Fatalf("expected descriptive strategy error, got %v", err)
+	}
+	err = Reprovider{Interval: "twice a day", Strategy: ReproviderStrategyAll}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Reprovider.Interval") {
+		t.Fatalf("expected descriptive interval error, got %v", err)
+	}
+}
+
+func TestProviderValidate(t *testing.T) {
+	p := NewDefault().Provider
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Enabled() || p.Workers() != DefaultProviderWorkerCount {
+		t.Fatal("expected providing to be on with the default worker count")
+	}
+
+	p = Provider{Strategy: ReproviderStrategyPinned, Provide: False, WorkerCount: 4, DelayBetween: "100ms"}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if p.Enabled() || p.Workers() != 4 {
+		t.Fatal("expected explicit provider settings to apply")
+	}
+
+	err := Provider{WorkerCount: -1}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Provider.WorkerCount") {
+		t.Fatalf("expected descriptive worker count error, got %v", err)
+	}
+	errs, ok := Provider{Strategy: "some", DelayBetween: "soon"}.Validate().(ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected two validation errors, got %v", errs)
+	}
+}
+
+func TestRoutingValidate(t *testing.T) {
+	r := Routing{
+		Type: RoutingTypeNone,
+		Routers: map[string]RouterConfig{
+			"gateway": {Type: RouterTypeHTTP, Endpoint: "https://gateway.example.com"},
+			"dht":     {Type: RouterTypeDHT},
+		},
+		Methods: map[string]Method{
+			MethodFindProviders: {RouterName: "gateway"},
+			MethodFindPeers:     {RouterName: "dht"},
+		},
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	r.Routers["bad"] = RouterConfig{Type: RouterTypeHTTP, Endpoint: "gateway.example.com"}
+	r.Routers["worse"] = RouterConfig{Type: "carrier-pigeon"}
+	r.Methods[MethodProvide] = Method{RouterName: "missing"}
+	r.Methods["teleport"] = Method{RouterName: "dht"}
+	errs, ok := r.Validate().(ValidationErrors)
+	if !ok || len(errs) != 4 {
+		t.Fatalf("expected four validation errors, got %v", r.Validate())
+	}
+	for i := 0; i < 10; i++ {
+		if again := r.Validate().Error(); again != errs.Error() {
+			t.Fatalf("expected a stable error order, got %q and %q", errs.Error(), again)
+		}
+	}
+}
+
+func TestMarshalStable(t *testing.T) {
+	c := new(Config)
+	c.Datastore = DefaultDatastoreConfig()
+	c.Gateway.HTTPHeaders = map[string][]string{
+		"X-B": {"2"}, "X-A": {"1"}, "X-C": {"3"}, "X-D": {"4"},
+	}
+
+	first, err := c.MarshalStable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		clone, err := c.Clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := clone.MarshalStable()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != string(first) {
+			t.Fatalf("expected stable output, got\n%s\nand\n%s", first, out)
+		}
+	}
+	if strings.Index(string(first), "X-A") > strings.Index(string(first), "X-B") {
+		t.Fatal("expected map keys to be sorted")
+	}
+}
+
+func TestExperimentsEnabled(t *testing.T) {
+	var e Experiments
+	if err := json.Unmarshal([]byte(`{"FilestoreEnabled":true,"Libp2pStreamMounting":true,"HostsSyncMode":"score"}`), &e); err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]bool{
+		"FilestoreEnabled":     true,
+		"filestore":            true,
+		"Libp2pStreamMounting": true,
+		"ShardingEnabled":      false,
+		"HostsSyncMode":        false,
+		"Teleportation":        false,
+	} {
+		if e.Enabled(name) != expected {
+			t.Errorf("%s: expected %v", name, expected)
+		}
+	}
+
+	out, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Experiments
+	if err := json.Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back != e {
+		t.Fatalf("expected experiments to round trip, got %+v", back)
+	}
+}
+
+func TestMDNSValidate(t *testing.T) {
+	m := MDNS{Enabled: true, Interval: 10}
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Tag() != DefaultMDNSServiceTag {
+		t.Fatalf("expected default service tag, got %s", m.Tag())
+	}
+
+	m.Interval = 0
+	if err := m.Validate(); err == nil {
+		t.Fatal("expected zero interval to be rejected while enabled")
+	}
+	m.Enabled = false
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddRemotePinningService(t *testing.T) {
+	c := new(Config)
+	if err := c.AddRemotePinningService("pinner", "https://pinner.example.com/psa", "s3cr3t-token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddRemotePinningService("pinner", "https://other.example.com", "x"); err == nil {
+		t.Fatal("expected duplicate name to be rejected")
+	}
+	if err := c.AddRemotePinningService("other", "pinner.example.com", "x"); err == nil {
+		t.Fatal("expected invalid endpoint to be rejected")
+	}
+
+	out, err := c.MarshalRedacted()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "s3cr3t-token") {
+		t.Fatal("pinning service key leaked into redacted output")
+	}
+	if !strings.Contains(string(out), "https://pinner.example.com/psa") {
+		t.Fatal("expected pinning service endpoint to be kept")
+	}
+}
+
+func TestPinningValidate(t *testing.T) {
+	c := new(Config)
+	if err := c.AddRemotePinningService("pinner", "https://pinner.example.com/psa", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Pinning.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Pinning.RemoteServices["broken"] = RemotePinningService{
+		API:      RemotePinningServiceAPI{Endpoint: "ftp://pinner.example.com"},
+		Policies: RemotePinningServicePolicies{MFS: RemotePinningServiceMFSPolicy{RepinInterval: "hourly"}},
+	}
+	errs, ok := c.Pinning.Validate().(ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected two validation errors, got %v", c.Pinning.Validate())
+	}
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "Pinning.RemoteServices.broken.API.Endpoint") {
+		t.Fatalf("expected Validate to check Pinning, got %v", err)
+	}
+}
+
+func TestConnMgrValidate(t *testing.T) {
+	valid := ConnMgr{Type: ConnMgrTypeBasic, LowWater: 600, HighWater: 900, GracePeriod: "20s"}
+	if err := valid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (ConnMgr{Type: ConnMgrTypeNone, LowWater: 10, HighWater: 1, GracePeriod: "never"}).Validate(); err != nil {
+		t.Fatalf("expected water marks to be ignored for type none, got %s", err)
+	}
+
+	for name, c := range map[string]ConnMgr{
+		"type":     {Type: "fancy", LowWater: 1, HighWater: 2},
+		"negative": {Type: ConnMgrTypeBasic, LowWater: -5, HighWater: 0},
+		"order":    {Type: ConnMgrTypeBasic, LowWater: 10, HighWater: 5},
+		"grace":    {Type: ConnMgrTypeBasic, LowWater: 1, HighWater: 2, GracePeriod: "soon"},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: expected %+v to be rejected", name, c)
+		}
+	}
+}
+
+func TestSetConnMgrType(t *testing.T) {
+	c := NewDefault()
+	if err := c.SetConnMgrType(ConnMgrTypeNone); err != nil {
+		t.Fatal(err)
+	}
+	if c.Swarm.ConnMgr != (ConnMgr{Type: ConnMgrTypeNone}) {
+		t.Fatalf("expected zeroed water marks and grace period, got %+v", c.Swarm.ConnMgr)
+	}
+	opts, err := c.Swarm.ConnMgr.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts != (ConnMgrOptions{}) {
+		t.Fatalf("expected no-op connection manager options, got %+v", opts)
+	}
+
+	if err := c.SetConnMgrType(ConnMgrTypeBasic); err != nil {
+		t.Fatal(err)
+	}
+	opts, err = c.Swarm.ConnMgr.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ConnMgrOptions{Enabled: true, LowWater: DefaultConnMgrLowWater, HighWater: DefaultConnMgrHighWater, GracePeriod: DefaultConnMgrGracePeriod}
+	if opts != expected {
+		t.Fatalf("expected %+v, got %+v", expected, opts)
+	}
+
+	if err := c.SetConnMgrType("fancy"); err == nil {
+		t.Fatal("expected unknown type to be rejected")
+	}
+
+	c.Swarm.ConnMgr = ConnMgr{Type: ConnMgrTypeNone, LowWater: 10, HighWater: 20}
+	if changed, _ := c.EnsureDefaults(); !changed || c.Swarm.ConnMgr != (ConnMgr{Type: ConnMgrTypeNone}) {
+		t.Fatalf("expected EnsureDefaults to clear the water marks, got %+v", c.Swarm.ConnMgr)
+	}
+}
+
+func TestRelayClient(t *testing.T) {
+	relay := "/ip4/18.237.54.123/tcp/4001/p2p/QmWJWGxKKaqZUW4xga2BCzT5FBtYDL8Cc5Q5jywd6xPt1g"
+	r := RelayClient{
+		Enabled: True,
+		StaticRelays: []string{
+			relay,
+			"/ip4/18.237.54.123/udp/4001/quic/p2p/QmWJWGxKKaqZUW4xga2BCzT5FBtYDL8Cc5Q5jywd6xPt1g",
+			"/ip4/54.213.128.120/tcp/4001/p2p/QmWm3vBCRuZcJMUT9jDZysoYBb66aokmSReX26UaMk8qq5",
+		},
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	infos, err := r.StaticRelayInfos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected two relays, got %v", infos)
+	}
+	for _, info := range infos {
+		if info.ID.Pretty() == "QmWJWGxKKaqZUW4xga2BCzT5FBtYDL8Cc5Q5jywd6xPt1g" && len(info.Addrs) != 2 {
+			t.Fatalf("expected both addresses of the first relay, got %v", info.Addrs)
+		}
+	}
+
+	r.StaticRelays = []string{relay, "/ip4/1.2.3.4/tcp/4001", "garbage"}
+	if errs, ok := r.Validate().(ValidationErrors); !ok || len(errs) != 2 {
+		t.Fatalf("expected two validation errors, got %v", r.Validate())
+	}
+	if _, err := r.StaticRelayInfos(); err == nil {
+		t.Fatal("expected relay without peer id to be rejected")
+	}
+}
+
+func TestValidateIpns(t *testing.T) {
+	i := Ipns{RecordLifetime: "1h", RepublishPeriod: "4h"}
+	if err := i.Validate(); err == nil {
+		t.Fatal("expected republish period longer than the record lifetime to be rejected")
+	}
+	i.RepublishPeriod = "1h"
+	if err := i.Validate(); err == nil {
+		t.Fatal("expected republish period equal to the record lifetime to be rejected")
+	}
+	i.RepublishPeriod = "30m"
+	if err := i.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	ttl := "a while"
+	i = Ipns{RecordLifetime: "forever", MaxCacheTTL: &ttl}
+	if errs, ok := i.Validate().(ValidationErrors); !ok || len(errs) != 2 {
+		t.Fatalf("expected two validation errors, got %v", i.Validate())
+	}
+}
+
+func TestValidateMounts(t *testing.T) {
+	if err := NewDefault().Mounts.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Mounts{}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := Mounts{IPFS: "/btfs", IPNS: "/btfs/"}
+	if err := m.Validate(); err == nil {
+		t.Fatal("expected identical mount points to be rejected")
+	}
+	m = Mounts{IPFS: "btfs", IPNS: "/btns"}
+	if err := m.Validate(); err == nil {
+		t.Fatal("expected relative mount point to be rejected")
+	}
+	m = Mounts{IPFS: "/btfs", IPNS: "", FuseAllowOther: true}
+	if err := m.Validate(); err == nil {
+		t.Fatal("expected empty mount point to be rejected")
+	}
+	m = Mounts{IPFS: "/", IPNS: "/btns"}
+	if err := m.Validate(); err == nil {
+		t.Fatal("expected root mount point to be rejected")
+	}
+}
+
+func TestValidateServices(t *testing.T) {
+	for _, s := range []Services{DefaultServicesConfig(), DefaultServicesConfigDev(), DefaultServicesConfigTestnet(), {}} {
+		if err := s.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if NewDefault().Services.HubDomain != "https://hub.btfs.io" {
+		t.Fatal("expected production service defaults")
+	}
+
+	s := DefaultServicesConfig()
+	s.HubDomain = "hub.staging"
+	s.SolidityDomain = "grpc.trongrid.io"
+	s.TrustedNodes = []string{"QmWJWGxKKaqZUW4xga2BCzT5FBtYDL8Cc5Q5jywd6xPt1g", "not-a-peer"}
+	if errs, ok := s.Validate().(ValidationErrors); !ok || len(errs) != 3 {
+		t.Fatalf("expected three validation errors, got %v", s.Validate())
+	}
+}
+
+func TestResetSection(t *testing.T) {
+	c := NewDefault()
+	c.Datastore.StorageMax = "1TB"
+	c.Datastore.Spec = badgerSpec()
+	c.Bootstrap = []string{"garbage"}
+	c.Gateway.Writable = true
+	if err := c.ResetSection("Datastore"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.Datastore, DefaultDatastoreConfig()) {
+		t.Fatalf("expected default datastore, got %+v", c.Datastore)
+	}
+	if err := c.ResetSection("bootstrap"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.Bootstrap, DefaultBootstrapAddresses) {
+		t.Fatalf("expected default bootstrap peers, got %v", c.Bootstrap)
+	}
+	if !c.Gateway.Writable {
+		t.Fatal("expected other sections to be left untouched")
+	}
+
+	c.Swarm.SwarmKey = DefaultTestnetSwarmKey
+	c.Swarm.ConnMgr.HighWater = 1
+	if err := c.ResetSection("Swarm"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Swarm.ConnMgr.HighWater != DefaultConnMgrHighWater || c.Swarm.SwarmKey != DefaultTestnetSwarmKey {
+		t.Fatalf("expected swarm defaults with the swarm key kept, got %+v", c.Swarm)
+	}
+
+	err := c.ResetSection("Identity")
+	if err == nil || !strings.Contains(err.Error(), "Addresses, Bootstrap, Datastore") {
+		t.Fatalf("expected error listing valid sections, got %v", err)
+	}
+}
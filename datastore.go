@@ -2,6 +2,13 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // DefaultDataStoreDirectory is the directory to store all the local IPFS data.
@@ -13,6 +20,11 @@ type Datastore struct {
 	StorageGCWatermark int64  // in percentage to multiply on StorageMax
 	GCPeriod           string // in ns, us, ms, s, m, h
 
+	// BasePath optionally records the directory the relative paths in Spec
+	// are resolved against, for tooling that doesn't know the repo location.
+	// Defaults to the repo root, see ResolveChildPaths.
+	BasePath string `json:",omitempty"`
+
 	// deprecated fields, use Spec
 	Type   string           `json:",omitempty"`
 	Path   string           `json:",omitempty"`
@@ -30,3 +42,274 @@ type Datastore struct {
 func DataStorePath(configroot string) (string, error) {
 	return Path(configroot, DefaultDataStoreDirectory)
 }
+
+// MaxBytes returns StorageMax in bytes. Both SI ("10GB") and binary
+// ("512MiB") suffixes are supported.
+func (d Datastore) MaxBytes() (uint64, error) {
+	return parseByteSize(d.StorageMax)
+}
+
+// GCThresholdBytes returns the repo size, in bytes, above which garbage
+// collection kicks in: StorageGCWatermark percent of StorageMax.
+func (d Datastore) GCThresholdBytes() (uint64, error) {
+	max, err := d.MaxBytes()
+	if err != nil {
+		return 0, err
+	}
+	if d.StorageGCWatermark < 0 || d.StorageGCWatermark > 100 {
+		return 0, fmt.Errorf("invalid StorageGCWatermark %d: must be between 0 and 100", d.StorageGCWatermark)
+	}
+	// divide first so large repos don't overflow.
+	wm := uint64(d.StorageGCWatermark)
+	return max/100*wm + max%100*wm/100, nil
+}
+
+// Validate checks the datastore size and garbage collection settings, and
+// that the relative paths in Spec stay below the datastore directory.
+func (d Datastore) Validate() error {
+	var errs ValidationErrors
+	if d.StorageMax != "" {
+		if _, err := d.MaxBytes(); err != nil {
+			errs.add(fieldErrorf("Datastore.StorageMax", "%s", err))
+		}
+	}
+	if d.StorageGCWatermark < 0 || d.StorageGCWatermark > 100 {
+		errs.add(fieldErrorf("Datastore.StorageGCWatermark", "must be between 0 and 100, got %d", d.StorageGCWatermark))
+	}
+	if d.GCPeriod != "" {
+		if _, err := time.ParseDuration(d.GCPeriod); err != nil {
+			errs.add(fieldErrorf("Datastore.GCPeriod", "%s", err))
+		}
+	}
+	if d.BloomFilterSize < 0 {
+		errs.add(fieldErrorf("Datastore.BloomFilterSize", "must not be negative, got %d", d.BloomFilterSize))
+	}
+	if d.Spec != nil {
+		children := map[string]string{}
+		if err := datastoreChildPaths(d.Spec, "/", children); err != nil {
+			errs.add(fieldErrorf("Datastore.Spec", "%s", err))
+		}
+		mountpoints := make([]string, 0, len(children))
+		for mountpoint := range children {
+			mountpoints = append(mountpoints, mountpoint)
+		}
+		sort.Strings(mountpoints)
+		for _, mountpoint := range mountpoints {
+			if err := checkChildPath(children[mountpoint]); err != nil {
+				errs.add(fieldErrorf("Datastore.Spec", "mount %s: %s", mountpoint, err))
+			}
+		}
+	}
+	return errs.errOrNil()
+}
+
+// datastoreLayoutNames maps the leaf datastore types of a spec to their name
+// in DatastoreLayout identifiers.
+var datastoreLayoutNames = map[string]string{
+	"flatfs":   "flatfs",
+	"levelds":  "leveldb",
+	"badgerds": "badgerds",
+	"mem":      "mem",
+}
+
+// DatastoreLayout returns a stable identifier of the on-disk layout implied
+// by Datastore.Spec, such as "flatfs+leveldb" for the default spec or
+// "badgerds". It lists the leaf datastores in mount order, looking through
+// mount, measure and log wrappers, so migration tooling can tell which
+// transform a repo needs.
+func (c *Config) DatastoreLayout() (string, error) {
+	leaves, err := datastoreLeaves(c.Datastore.Spec)
+	if err != nil {
+		return "", fmt.Errorf("unrecognized datastore spec: %s", err)
+	}
+	return strings.Join(leaves, "+"), nil
+}
+
+// ResolveChildPaths returns the absolute directory of every datastore in
+// Spec that is stored on disk, keyed by mountpoint. The relative paths in
+// Spec are resolved against BasePath or, when it's unset, the repo root given
+// by PathRoot; relative paths escaping that directory are rejected. Absolute
+// paths, e.g. blocks on a second disk, are returned unchanged.
+func (d Datastore) ResolveChildPaths() (map[string]string, error) {
+	base := d.BasePath
+	if base == "" {
+		root, err := PathRoot()
+		if err != nil {
+			return nil, err
+		}
+		base = root
+	}
+	base, err := filepath.Abs(base)
+	if err != nil {
+		return nil, err
+	}
+
+	children := map[string]string{}
+	if err := datastoreChildPaths(d.Spec, "/", children); err != nil {
+		return nil, fmt.Errorf("unrecognized datastore spec: %s", err)
+	}
+	for mountpoint, p := range children {
+		if err := checkChildPath(p); err != nil {
+			return nil, fmt.Errorf("mount %s: %s", mountpoint, err)
+		}
+		if !filepath.IsAbs(p) {
+			children[mountpoint] = filepath.Join(base, p)
+		}
+	}
+	return children, nil
+}
+
+// datastoreChildPaths records the path of every leaf datastore of spec
+// mounted at mountpoint, looking through the same wrappers as
+// DatastoreLayout.
+func datastoreChildPaths(spec map[string]interface{}, mountpoint string, out map[string]string) error {
+	t, _ := spec["type"].(string)
+	switch t {
+	case "mount":
+		mounts, ok := spec["mounts"].([]interface{})
+		if !ok {
+			return fmt.Errorf("mount without mounts")
+		}
+		for _, m := range mounts {
+			mm, ok := m.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("mount entry is not an object")
+			}
+			mp, ok := mm["mountpoint"].(string)
+			if !ok {
+				return fmt.Errorf("mount entry without mountpoint")
+			}
+			if err := datastoreChildPaths(mm, mp, out); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "measure", "log":
+		child, ok := spec["child"].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s without child", t)
+		}
+		return datastoreChildPaths(child, mountpoint, out)
+	default:
+		if p, ok := spec["path"].(string); ok {
+			out[mountpoint] = p
+		}
+		return nil
+	}
+}
+
+// checkChildPath rejects relative datastore paths that don't stay below the
+// directory they are resolved against.
+func checkChildPath(p string) error {
+	if filepath.IsAbs(p) {
+		return nil
+	}
+	clean := filepath.Clean(p)
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path %q escapes the datastore directory", p)
+	}
+	return nil
+}
+
+func datastoreLeaves(spec map[string]interface{}) ([]string, error) {
+	t, _ := spec["type"].(string)
+	switch t {
+	case "mount":
+		mounts, ok := spec["mounts"].([]interface{})
+		if !ok || len(mounts) == 0 {
+			return nil, fmt.Errorf("mount without mounts")
+		}
+		var leaves []string
+		for _, m := range mounts {
+			mm, ok := m.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("mount entry is not an object")
+			}
+			l, err := datastoreLeaves(mm)
+			if err != nil {
+				return nil, err
+			}
+			leaves = append(leaves, l...)
+		}
+		return leaves, nil
+	case "measure", "log":
+		child, ok := spec["child"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s without child", t)
+		}
+		return datastoreLeaves(child)
+	case "":
+		return nil, fmt.Errorf("missing datastore type")
+	default:
+		name, ok := datastoreLayoutNames[t]
+		if !ok {
+			return nil, fmt.Errorf("unknown datastore type %q", t)
+		}
+		return []string{name}, nil
+	}
+}
+
+var byteSizeUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"ki":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1e6,
+	"mb":  1e6,
+	"mi":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1e9,
+	"gb":  1e9,
+	"gi":  1 << 30,
+	"gib": 1 << 30,
+	"t":   1e12,
+	"tb":  1e12,
+	"ti":  1 << 40,
+	"tib": 1 << 40,
+	"p":   1e15,
+	"pb":  1e15,
+	"pi":  1 << 50,
+	"pib": 1 << 50,
+	"e":   1e18,
+	"eb":  1e18,
+	"ei":  1 << 60,
+	"eib": 1 << 60,
+}
+
+// parseByteSize parses a human readable byte quantity such as "10GB" or
+// "512MiB". SI (kB, MB, ...) and binary (KiB, MiB, ...) suffixes are both
+// accepted, case insensitively.
+func parseByteSize(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	num, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	if num == "" {
+		return 0, fmt.Errorf("invalid byte size %q: missing number", s)
+	}
+	mult, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size %q: unknown unit %q", s, s[i:])
+	}
+	if n, err := strconv.ParseUint(num, 10, 64); err == nil {
+		if n > math.MaxUint64/mult {
+			return 0, fmt.Errorf("invalid byte size %q: too large", s)
+		}
+		return n * mult, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q: %s", s, err)
+	}
+	f *= float64(mult)
+	if f >= math.MaxUint64 {
+		return 0, fmt.Errorf("invalid byte size %q: too large", s)
+	}
+	return uint64(f), nil
+}
@@ -0,0 +1,122 @@
+package config
+
+import "fmt"
+
+// LevelDB compression modes accepted by DatastoreOptions.
+const (
+	LevelDBCompressionNone   = "none"
+	LevelDBCompressionSnappy = "snappy"
+)
+
+// DatastoreOptions tunes the children of the default datastore spec, see
+// NewDatastoreConfig.
+type DatastoreOptions struct {
+	// FlatFSSync makes flatfs fsync every write. Turning it off is only
+	// safe on disks with a battery backed write cache.
+	FlatFSSync bool
+
+	// LevelDBCompression is "none" or "snappy".
+	LevelDBCompression string
+}
+
+// DefaultDatastoreOptions returns the options of DefaultDatastoreConfig.
+func DefaultDatastoreOptions() DatastoreOptions {
+	return DatastoreOptions{
+		FlatFSSync:         true,
+		LevelDBCompression: LevelDBCompressionNone,
+	}
+}
+
+// Validate checks the leveldb compression mode.
+func (o DatastoreOptions) Validate() error {
+	switch o.LevelDBCompression {
+	case LevelDBCompressionNone, LevelDBCompressionSnappy:
+		return nil
+	default:
+		return fmt.Errorf("unknown leveldb compression %q, must be %q or %q",
+			o.LevelDBCompression, LevelDBCompressionNone, LevelDBCompressionSnappy)
+	}
+}
+
+// ChildSpec describes a leaf datastore mounted by a DatastoreSpec.
+type ChildSpec map[string]interface{}
+
+// FlatFS returns a flatfs child datastore stored under path.
+func FlatFS(path, shardFunc string, sync bool) ChildSpec {
+	return ChildSpec{
+		"type":      "flatfs",
+		"path":      path,
+		"sync":      sync,
+		"shardFunc": shardFunc,
+	}
+}
+
+// LevelDB returns a leveldb child datastore stored under path.
+func LevelDB(path, compression string) ChildSpec {
+	return ChildSpec{
+		"type":        "levelds",
+		"path":        path,
+		"compression": compression,
+	}
+}
+
+// Badger returns a badger child datastore stored under path.
+func Badger(path string, syncWrites, truncate bool) ChildSpec {
+	return ChildSpec{
+		"type":       "badgerds",
+		"path":       path,
+		"syncWrites": syncWrites,
+		"truncate":   truncate,
+	}
+}
+
+// DatastoreSpec builds a mount datastore spec, the format of Datastore.Spec.
+type DatastoreSpec struct {
+	mounts []mountSpec
+}
+
+type mountSpec struct {
+	mountpoint string
+	prefix     string
+	child      ChildSpec
+}
+
+// NewDatastoreSpec returns an empty mount spec.
+func NewDatastoreSpec() *DatastoreSpec {
+	return &DatastoreSpec{}
+}
+
+// Mount adds child at mountpoint, wrapped in a measure datastore reporting
+// metrics under prefix.
+func (s *DatastoreSpec) Mount(mountpoint, prefix string, child ChildSpec) *DatastoreSpec {
+	s.mounts = append(s.mounts, mountSpec{mountpoint, prefix, child})
+	return s
+}
+
+// Build returns the spec as stored in Datastore.Spec. Every call returns a
+// new map.
+func (s *DatastoreSpec) Build() map[string]interface{} {
+	mounts := make([]interface{}, len(s.mounts))
+	for i, m := range s.mounts {
+		mounts[i] = map[string]interface{}{
+			"mountpoint": m.mountpoint,
+			"type":       "measure",
+			"prefix":     m.prefix,
+			"child":      m.child.build(),
+		}
+	}
+	return map[string]interface{}{
+		"type":   "mount",
+		"mounts": mounts,
+	}
+}
+
+// build copies the child into a plain map, the type produced when decoding
+// a spec from JSON.
+func (c ChildSpec) build() map[string]interface{} {
+	m := make(map[string]interface{}, len(c))
+	for k, v := range c {
+		m[k] = v
+	}
+	return m
+}
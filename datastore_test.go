@@ -0,0 +1,277 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMaxBytes(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		out uint64
+	}{
+		{"1024", 1024},
+		{"10GB", 10 * 1000 * 1000 * 1000},
+		{"10gb", 10 * 1000 * 1000 * 1000},
+		{"512MiB", 512 << 20},
+		{"1TB", 1000 * 1000 * 1000 * 1000},
+		{"1.5 KiB", 1536},
+		{"64kB", 64000},
+	} {
+		d := Datastore{StorageMax: tc.in}
+		n, err := d.MaxBytes()
+		if err != nil {
+			t.Fatalf("%s: %s", tc.in, err)
+		}
+		if n != tc.out {
+			t.Fatalf("%s: expected %d, got %d", tc.in, tc.out, n)
+		}
+	}
+
+	for _, in := range []string{"", "GB", "10 gigs", "1..5GB", "20EiB"} {
+		d := Datastore{StorageMax: in}
+		if _, err := d.MaxBytes(); err == nil {
+			t.Fatalf("expected %q to be rejected", in)
+		}
+	}
+}
+
+func TestDefaultBadgerDatastoreConfig(t *testing.T) {
+	expected := map[string]interface{}{
+		"type":   "measure",
+		"prefix": "badger.datastore",
+		"child": map[string]interface{}{
+			"type":       "badgerds",
+			"path":       "badgerds",
+			"syncWrites": false,
+			"truncate":   true,
+		},
+	}
+	ds := DefaultBadgerDatastoreConfig()
+	if !reflect.DeepEqual(ds.Spec, expected) {
+		t.Fatalf("expected spec %v, got %v", expected, ds.Spec)
+	}
+	if ds.StorageMax != DefaultDatastoreConfig().StorageMax {
+		t.Fatal("expected badger config to keep the default storage settings")
+	}
+
+	c := &Config{Datastore: DefaultDatastoreConfig()}
+	if err := Profiles["badgerds"].Transform(c); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.Datastore.Spec, expected) {
+		t.Fatalf("expected badgerds profile to apply spec %v, got %v", expected, c.Datastore.Spec)
+	}
+}
+
+func TestDatastoreValidateGC(t *testing.T) {
+	for _, tc := range []struct {
+		watermark int64
+		ok        bool
+	}{
+		{0, true},
+		{100, true},
+		{101, false},
+		{-1, false},
+	} {
+		d := Datastore{StorageMax: "10GB", StorageGCWatermark: tc.watermark, GCPeriod: "1h"}
+		if err := d.Validate(); (err == nil) != tc.ok {
+			t.Fatalf("watermark %d: unexpected validation result %v", tc.watermark, err)
+		}
+	}
+
+	d := Datastore{GCPeriod: "hourly", BloomFilterSize: -1}
+	if errs, ok := d.Validate().(ValidationErrors); !ok || len(errs) != 2 {
+		t.Fatalf("expected two validation errors, got %v", d.Validate())
+	}
+}
+
+func TestGCThresholdBytes(t *testing.T) {
+	d := Datastore{StorageMax: "10GB", StorageGCWatermark: 90}
+	n, err := d.GCThresholdBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 9*1000*1000*1000 {
+		t.Fatalf("expected 9GB, got %d", n)
+	}
+
+	d = Datastore{StorageMax: "199", StorageGCWatermark: 50}
+	if n, _ := d.GCThresholdBytes(); n != 99 {
+		t.Fatalf("expected 99, got %d", n)
+	}
+
+	d.StorageGCWatermark = 101
+	if _, err := d.GCThresholdBytes(); err == nil {
+		t.Fatal("expected out of range watermark to be rejected")
+	}
+}
+
+func TestDatastoreSpecBuilder(t *testing.T) {
+	expected := map[string]interface{}{
+		"type": "mount",
+		"mounts": []interface{}{
+			map[string]interface{}{
+				"mountpoint": "/blocks",
+				"type":       "measure",
+				"prefix":     "flatfs.datastore",
+				"child": map[string]interface{}{
+					"type":      "flatfs",
+					"path":      "blocks",
+					"sync":      true,
+					"shardFunc": "/repo/flatfs/shard/v1/next-to-last/2",
+				},
+			},
+			map[string]interface{}{
+				"mountpoint": "/",
+				"type":       "measure",
+				"prefix":     "leveldb.datastore",
+				"child": map[string]interface{}{
+					"type":        "levelds",
+					"path":        "datastore",
+					"compression": "none",
+				},
+			},
+		},
+	}
+	if spec := DefaultDatastoreConfig().Spec; !reflect.DeepEqual(spec, expected) {
+		t.Fatalf("expected %v, got %v", expected, spec)
+	}
+
+	b := NewDatastoreSpec().Mount("/", "badger.datastore", Badger("badgerds", false, true))
+	first := b.Build()
+	first["mounts"].([]interface{})[0].(map[string]interface{})["child"].(map[string]interface{})["path"] = "changed"
+	if !reflect.DeepEqual(b.Build()["mounts"].([]interface{})[0].(map[string]interface{})["child"], map[string]interface{}{
+		"type": "badgerds", "path": "badgerds", "syncWrites": false, "truncate": true,
+	}) {
+		t.Fatal("expected Build to return independent specs")
+	}
+}
+
+func TestNewDatastoreConfig(t *testing.T) {
+	ds, err := NewDatastoreConfig(DatastoreOptions{FlatFSSync: false, LevelDBCompression: LevelDBCompressionSnappy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mounts := ds.Spec["mounts"].([]interface{})
+	flatfs := mounts[0].(map[string]interface{})["child"].(map[string]interface{})
+	leveldb := mounts[1].(map[string]interface{})["child"].(map[string]interface{})
+	if flatfs["sync"] != false {
+		t.Fatalf("expected flatfs sync off, got %v", flatfs["sync"])
+	}
+	if leveldb["compression"] != "snappy" {
+		t.Fatalf("expected snappy compression, got %v", leveldb["compression"])
+	}
+	if err := ds.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewDatastoreConfig(DatastoreOptions{LevelDBCompression: "zstd"}); err == nil {
+		t.Fatal("expected unknown compression to be rejected")
+	}
+}
+
+func TestDatastoreLayout(t *testing.T) {
+	c := new(Config)
+	c.Datastore = DefaultDatastoreConfig()
+	if layout, err := c.DatastoreLayout(); err != nil || layout != "flatfs+leveldb" {
+		t.Fatalf("expected flatfs+leveldb, got %q (%v)", layout, err)
+	}
+	c.Datastore = DefaultBadgerDatastoreConfig()
+	if layout, err := c.DatastoreLayout(); err != nil || layout != "badgerds" {
+		t.Fatalf("expected badgerds, got %q (%v)", layout, err)
+	}
+
+	for _, spec := range []map[string]interface{}{
+		nil,
+		{"type": "mount", "mounts": []interface{}{}},
+		{"type": "measure", "child": map[string]interface{}{"type": "s3ds"}},
+	} {
+		c.Datastore.Spec = spec
+		if _, err := c.DatastoreLayout(); err == nil {
+			t.Fatalf("expected spec %v to be unrecognized", spec)
+		}
+	}
+}
+
+func TestResolveChildPaths(t *testing.T) {
+	base := filepath.Join(os.TempDir(), "btfs-repo")
+	d := DefaultDatastoreConfig()
+	d.BasePath = base
+	paths, err := d.ResolveChildPaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"/blocks": filepath.Join(base, "blocks"),
+		"/":       filepath.Join(base, "datastore"),
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("expected %v, got %v", expected, paths)
+	}
+
+	if prev, ok := os.LookupEnv(EnvDir); ok {
+		defer os.Setenv(EnvDir, prev)
+	} else {
+		defer os.Unsetenv(EnvDir)
+	}
+	os.Setenv(EnvDir, base)
+	d.BasePath = ""
+	if paths, err := d.ResolveChildPaths(); err != nil || !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("expected paths below the repo root, got %v (%v)", paths, err)
+	}
+
+	for _, p := range []string{"../blocks", "blocks/../../x", "."} {
+		d := DefaultDatastoreConfig()
+		d.Spec["mounts"].([]interface{})[0].(map[string]interface{})["child"].(map[string]interface{})["path"] = p
+		if _, err := d.ResolveChildPaths(); err == nil {
+			t.Errorf("%s: expected escaping path to be rejected", p)
+		}
+		if err := d.Validate(); err == nil {
+			t.Errorf("%s: expected escaping path to fail validation", p)
+		}
+	}
+}
+
+func TestDatastoreValidateMalformedSpec(t *testing.T) {
+	d := DefaultDatastoreConfig()
+	d.Spec = map[string]interface{}{"type": "mount", "mounts": []interface{}{"blocks"}}
+	if err := d.Validate(); err == nil {
+		t.Fatal("expected malformed spec to be rejected")
+	}
+}
+
+func TestDatastoreAbsoluteChildPath(t *testing.T) {
+	c, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := filepath.Join(os.TempDir(), "disk2", "blocks")
+	c.Datastore.Spec["mounts"].([]interface{})[0].(map[string]interface{})["child"].(map[string]interface{})["path"] = blocks
+
+	dir, err := ioutil.TempDir("", "btfs-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config")
+	if err := WriteFile(file, c); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(file)
+	if err != nil {
+		t.Fatalf("expected a spec with an absolute path to load, got %s", err)
+	}
+
+	loaded.Datastore.BasePath = dir
+	paths, err := loaded.Datastore.ResolveChildPaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths["/blocks"] != blocks || paths["/"] != filepath.Join(dir, "datastore") {
+		t.Fatalf("unexpected paths %v", paths)
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ConfigChange is a single difference reported by Diff. Old is nil for added
+// values and New is nil for removed ones.
+type ConfigChange struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+// Diff compares two configs and returns the changed leaf values, sorted by
+// their dot separated path. Lists are compared as a whole. Values listed in
+// SensitivePaths are replaced by RedactedValue.
+func Diff(a, b *Config) ([]ConfigChange, error) {
+	am, err := a.ToMap()
+	if err != nil {
+		return nil, err
+	}
+	bm, err := b.ToMap()
+	if err != nil {
+		return nil, err
+	}
+	var changes []ConfigChange
+	diffMaps(nil, am, bm, &changes)
+	return changes, nil
+}
+
+func diffMaps(path []string, a, b map[string]interface{}, changes *[]ConfigChange) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		p := append(path[:len(path):len(path)], k)
+		av, bv := a[k], b[k]
+		am, aIsMap := av.(map[string]interface{})
+		bm, bIsMap := bv.(map[string]interface{})
+		switch {
+		case aIsMap && bIsMap:
+			diffMaps(p, am, bm, changes)
+		case aIsMap && bv == nil:
+			diffMaps(p, am, nil, changes)
+		case bIsMap && av == nil:
+			diffMaps(p, nil, bm, changes)
+		case !reflect.DeepEqual(av, bv):
+			change := ConfigChange{Path: strings.Join(p, "."), Old: av, New: bv}
+			if isSensitivePath(p) {
+				if change.Old != nil {
+					change.Old = RedactedValue
+				}
+				if change.New != nil {
+					change.New = RedactedValue
+				}
+			}
+			*changes = append(*changes, change)
+		}
+	}
+}
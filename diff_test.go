@@ -0,0 +1,58 @@
+package config
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := a.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := Diff(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no changes, got %v", changes)
+	}
+
+	b.Swarm.ConnMgr.HighWater = 1234
+	b.Bootstrap = append([]string{}, a.Bootstrap[1:]...)
+	changes, err = Diff(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Path != "Bootstrap" || changes[1].Path != "Swarm.ConnMgr.HighWater" {
+		t.Fatalf("unexpected changes %v", changes)
+	}
+	if changes[1].Old != float64(a.Swarm.ConnMgr.HighWater) || changes[1].New != float64(1234) {
+		t.Fatalf("unexpected HighWater change %v", changes[1])
+	}
+
+	b, _ = a.Clone()
+	b.Identity.PrivKey = "changed"
+	b.Pinning.RemoteServices = map[string]RemotePinningService{
+		"pinata": {API: RemotePinningServiceAPI{Endpoint: "https://api.pinata.cloud", Key: "secret"}},
+	}
+	changes, err = Diff(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ConfigChange{
+		{Path: "Identity.PrivKey", Old: RedactedValue, New: RedactedValue},
+		{Path: "Pinning.RemoteServices.pinata.API.Endpoint", New: "https://api.pinata.cloud"},
+		{Path: "Pinning.RemoteServices.pinata.API.Key", New: RedactedValue},
+	}
+	// the new service also adds its zero valued policies.
+	if len(changes) < len(expected) || !reflect.DeepEqual(changes[:len(expected)], expected) {
+		t.Fatalf("expected %v, got %v", expected, changes)
+	}
+}
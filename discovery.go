@@ -1,5 +1,8 @@
 package config
 
+// DefaultMDNSServiceTag is the standard MDNS service tag of libp2p nodes.
+const DefaultMDNSServiceTag = "_ipfs-discovery._udp"
+
 type Discovery struct {
 	MDNS MDNS
 }
@@ -9,4 +12,25 @@ type MDNS struct {
 
 	// Time in seconds between discovery rounds
 	Interval int
+
+	// ServiceTag is the MDNS service name to announce and query. Set a
+	// custom tag to avoid discovering unrelated nodes on the LAN. Defaults
+	// to DefaultMDNSServiceTag when empty.
+	ServiceTag string `json:",omitempty"`
+}
+
+// Tag returns the configured service tag, or DefaultMDNSServiceTag.
+func (m MDNS) Tag() string {
+	if m.ServiceTag == "" {
+		return DefaultMDNSServiceTag
+	}
+	return m.ServiceTag
+}
+
+// Validate checks that an enabled MDNS service has a positive interval.
+func (m MDNS) Validate() error {
+	if m.Enabled && m.Interval <= 0 {
+		return fieldErrorf("Discovery.MDNS.Interval", "must be a positive number of seconds when MDNS is enabled, got %d", m.Interval)
+	}
+	return nil
 }
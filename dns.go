@@ -0,0 +1,83 @@
+package config
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultDNSResolverKey is the DNS.Resolvers key of the resolver used for
+// every domain without a more specific entry.
+const DefaultDNSResolverKey = "."
+
+// DNS configures the resolvers used for DNSLink and other DNS lookups.
+type DNS struct {
+	// Resolvers maps a TLD, e.g. "eth.", to the DNS over HTTPS resolver
+	// serving it. The "." key replaces the system resolver for everything
+	// else.
+	Resolvers map[string]string `json:",omitempty"`
+
+	// MaxCacheTTL caps the time DNS answers are cached, whatever their TTL.
+	MaxCacheTTL *string `json:",omitempty"`
+}
+
+// dnsLabel matches a single DNS label.
+var dnsLabel = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ResolverFor returns the resolver URL for tld, falling back to the "."
+// resolver. An empty result means the system resolver. tld is matched case
+// insensitively, with or without its trailing dot. Validate rejects keys that
+// name the same TLD; should there be any, the first in sorted order wins.
+func (d DNS) ResolverFor(tld string) string {
+	tld = normalizeTLD(tld)
+	for _, key := range d.sortedResolverKeys() {
+		if key != DefaultDNSResolverKey && normalizeTLD(key) == tld {
+			return d.Resolvers[key]
+		}
+	}
+	return d.Resolvers[DefaultDNSResolverKey]
+}
+
+// normalizeTLD lowercases tld and strips its trailing dot.
+func normalizeTLD(tld string) string {
+	return strings.TrimSuffix(strings.ToLower(tld), ".")
+}
+
+func (d DNS) sortedResolverKeys() []string {
+	keys := make([]string, 0, len(d.Resolvers))
+	for key := range d.Resolvers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate checks that every resolver is keyed by "." or a valid TLD and
+// points to an https URL, and that no two keys name the same TLD.
+func (d DNS) Validate() error {
+	var errs ValidationErrors
+	seen := make(map[string]string, len(d.Resolvers))
+	for _, key := range d.sortedResolverKeys() {
+		if key != DefaultDNSResolverKey {
+			tld := normalizeTLD(key)
+			if !dnsLabel.MatchString(tld) {
+				errs.add(fieldErrorf("DNS.Resolvers", "%q is not a TLD", key))
+			} else if other, ok := seen[tld]; ok {
+				errs.add(fieldErrorf("DNS.Resolvers", "%q and %q name the same TLD", other, key))
+			}
+			seen[tld] = key
+		}
+		resolver := d.Resolvers[key]
+		if u, err := url.Parse(resolver); err != nil || u.Scheme != "https" || u.Host == "" {
+			errs.add(fieldErrorf("DNS.Resolvers."+key, "%q is not an https URL", resolver))
+		}
+	}
+	if d.MaxCacheTTL != nil {
+		if _, err := time.ParseDuration(*d.MaxCacheTTL); err != nil {
+			errs.add(fieldErrorf("DNS.MaxCacheTTL", "%s", err))
+		}
+	}
+	return errs.errOrNil()
+}
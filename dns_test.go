@@ -0,0 +1,65 @@
+package config
+
+import "testing"
+
+func TestDNSResolverFor(t *testing.T) {
+	d := DNS{Resolvers: map[string]string{
+		"eth.":   "https://resolver.cloudflare-eth.com/dns-query",
+		"crypto": "https://resolver.unstoppable.io/dns-query",
+	}}
+	if r := d.ResolverFor("ETH"); r != d.Resolvers["eth."] {
+		t.Fatalf("expected the eth resolver, got %q", r)
+	}
+	if r := d.ResolverFor("crypto."); r != d.Resolvers["crypto"] {
+		t.Fatalf("expected the crypto resolver, got %q", r)
+	}
+	if r := d.ResolverFor("com"); r != "" {
+		t.Fatalf("expected the system resolver, got %q", r)
+	}
+
+	d.Resolvers["."] = "https://cloudflare-dns.com/dns-query"
+	if r := d.ResolverFor("com"); r != d.Resolvers["."] {
+		t.Fatalf("expected the default resolver, got %q", r)
+	}
+	if err := d.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDNSValidate(t *testing.T) {
+	if err := (DNS{}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	ttl := "forever"
+	d := DNS{
+		Resolvers: map[string]string{
+			"eth.":      "http://resolver.example.com/dns-query",
+			"not a tld": "https://resolver.example.com/dns-query",
+			".":         "resolver.example.com",
+		},
+		MaxCacheTTL: &ttl,
+	}
+	if errs, ok := d.Validate().(ValidationErrors); !ok || len(errs) != 4 {
+		t.Fatalf("expected four validation errors, got %v", d.Validate())
+	}
+}
+
+func TestDNSDuplicateTLD(t *testing.T) {
+	d := DNS{Resolvers: map[string]string{
+		"eth.": "https://a.example.com/dns-query",
+		"ETH":  "https://b.example.com/dns-query",
+	}}
+	for i := 0; i < 10; i++ {
+		if r := d.ResolverFor("eth"); r != d.Resolvers["ETH"] {
+			t.Fatalf("expected the first key in sorted order to win, got %q", r)
+		}
+	}
+	errs, ok := d.Validate().(ValidationErrors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("expected one validation error, got %v", d.Validate())
+	}
+	if fe, ok := errs[0].(FieldError); !ok || fe.Path != "DNS.Resolvers" {
+		t.Fatalf("unexpected error %v", errs[0])
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import "errors"
+
+// Finding codes reported by Doctor.
+const (
+	// CodeInvalidValue marks a Validate error.
+	CodeInvalidValue = "invalid-value"
+
+	CodePlaintextPrivateKey = "plaintext-private-key"
+	CodeListenConflict      = "listen-conflict"
+	CodePrivateAnnounce     = "private-announce"
+	CodeLowHighWater        = "low-high-water"
+	CodeMDNSFiltered        = "mdns-filtered"
+	CodePublicBootstrap     = "public-bootstrap"
+)
+
+// Finding is a single problem reported by Doctor.
+type Finding struct {
+	Code    string // stable identifier of the kind of problem
+	Path    string // dot separated config path, if known
+	Message string
+}
+
+// String formats the finding as "Path: Message".
+func (f Finding) String() string {
+	if f.Path == "" {
+		return f.Message
+	}
+	return f.Path + ": " + f.Message
+}
+
+// Report holds every finding of Doctor. A config with Errors is unusable, one
+// with only Warnings works but is probably not what the user wants.
+type Report struct {
+	Errors   []Finding
+	Warnings []Finding
+}
+
+// OK reports whether the config has no errors.
+func (r Report) OK() bool {
+	return len(r.Errors) == 0
+}
+
+// Doctor runs every check of the package, Validate and Warnings (which
+// includes ListenConflicts and HasPlaintextPrivateKey), and returns their
+// findings.
+func (c *Config) Doctor() Report {
+	var r Report
+	if err := c.Validate(); err != nil {
+		errs, ok := err.(ValidationErrors)
+		if !ok {
+			errs = ValidationErrors{err}
+		}
+		for _, e := range errs {
+			f := Finding{Code: CodeInvalidValue, Message: e.Error()}
+			var fe FieldError
+			if errors.As(e, &fe) {
+				f.Path, f.Message = fe.Path, fe.Message
+			}
+			r.Errors = append(r.Errors, f)
+		}
+	}
+	r.Warnings = c.warningFindings()
+	return r
+}
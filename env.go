@@ -0,0 +1,146 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvOverridePrefix prefixes every environment variable read by
+// ApplyEnvOverrides.
+const EnvOverridePrefix = "BTFS_"
+
+// ApplyEnvOverrides overrides config fields from environment variables
+// looked up with getenv (usually os.Getenv).
+//
+// The variable for a field is EnvOverridePrefix followed by its path, upper
+// cased and joined with underscores. For example Swarm.ConnMgr.HighWater is
+// read from BTFS_SWARM_CONNMGR_HIGHWATER and Routing.Type from
+// BTFS_ROUTING_TYPE. Empty or unset variables are ignored. Lists are given
+// as comma separated values, flags as true, false or default, and optional
+// values are allocated when set. Maps and lists of structs can't be
+// overridden, setting their variable is an error.
+func (c *Config) ApplyEnvOverrides(getenv func(string) string) error {
+	_, err := applyEnvOverrides(reflect.ValueOf(c).Elem(), strings.TrimSuffix(EnvOverridePrefix, "_"), getenv)
+	return err
+}
+
+func applyEnvOverrides(v reflect.Value, name string, getenv func(string) string) (bool, error) {
+	if v.Kind() == reflect.Struct && !isEnvLeaf(v) {
+		changed := false
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			c, err := applyEnvOverrides(v.Field(i), name+"_"+strings.ToUpper(f.Name), getenv)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || c
+		}
+		return changed, nil
+	}
+
+	if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct {
+		// only allocate optional sections when something is set.
+		elem := reflect.New(v.Type().Elem())
+		if !v.IsNil() {
+			elem.Elem().Set(v.Elem())
+		}
+		changed, err := applyEnvOverrides(elem.Elem(), name, getenv)
+		if err != nil || !changed {
+			return false, err
+		}
+		v.Set(elem)
+		return true, nil
+	}
+
+	value := getenv(name)
+	if value == "" {
+		return false, nil
+	}
+	if err := setEnvValue(v, value); err != nil {
+		return false, fmt.Errorf("invalid value for %s: %s", name, err)
+	}
+	return true, nil
+}
+
+// isEnvLeaf reports whether a struct value decodes itself from text.
+func isEnvLeaf(v reflect.Value) bool {
+	_, ok := v.Addr().Interface().(encoding.TextUnmarshaler)
+	return ok
+}
+
+func setEnvValue(v reflect.Value, value string) error {
+	if v.CanAddr() {
+		switch u := v.Addr().Interface().(type) {
+		case encoding.TextUnmarshaler:
+			return u.UnmarshalText([]byte(value))
+		case *Flag:
+			if value == "default" {
+				*u = Default
+				return nil
+			}
+			return u.UnmarshalJSON([]byte(value))
+		case json.Unmarshaler:
+			if v.Kind() != reflect.Slice {
+				return u.UnmarshalJSON([]byte(value))
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(v.Type().Elem())
+		if err := setEnvValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type %s", v.Type())
+		}
+		parts := strings.Split(value, ",")
+		s := reflect.MakeSlice(v.Type(), 0, len(parts))
+		for _, p := range parts {
+			if p = strings.TrimSpace(p); p != "" {
+				s = reflect.Append(s, reflect.ValueOf(p).Convert(v.Type().Elem()))
+			}
+		}
+		v.Set(s)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestApplyEnvOverrides(t *testing.T) {
+	env := map[string]string{
+		"BTFS_SWARM_CONNMGR_HIGHWATER":            "100",
+		"BTFS_ROUTING_TYPE":                       "dhtclient",
+		"BTFS_DISCOVERY_MDNS_ENABLED":             "false",
+		"BTFS_BOOTSTRAP":                          "a, b",
+		"BTFS_ADDRESSES_API":                      "/ip4/127.0.0.1/tcp/5002",
+		"BTFS_SWARM_TRANSPORTS_NETWORK_QUIC":      "false",
+		"BTFS_AUTONAT_SERVICEMODE":                "disabled",
+		"BTFS_AUTONAT_THROTTLE_INTERVAL":          "2m",
+		"BTFS_SWARM_TRANSPORTS_SECURITY_SECIO":    "false",
+		"BTFS_UNKNOWN_FIELD":                      "ignored",
+		"BTFS_EXPERIMENTAL_STORAGEHOSTENABLED":    "true",
+		"BTFS_SWARM_RELAYSERVICE_RESERVATIONTTL":  "1h",
+		"BTFS_SWARM_RELAYSERVICE_MAXRESERVATIONS": "64",
+	}
+	getenv := func(k string) string { return env[k] }
+
+	c := new(Config)
+	c.Discovery.MDNS.Enabled = true
+	c.Swarm.ConnMgr.LowWater = 50
+	if err := c.ApplyEnvOverrides(getenv); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Swarm.ConnMgr.HighWater != 100 || c.Swarm.ConnMgr.LowWater != 50 {
+		t.Fatalf("unexpected conn mgr %+v", c.Swarm.ConnMgr)
+	}
+	if c.Routing.Type != "dhtclient" || c.Discovery.MDNS.Enabled || !c.Experimental.StorageHostEnabled {
+		t.Fatal("expected string and bool overrides to be applied")
+	}
+	if !reflect.DeepEqual(c.Bootstrap, []string{"a", "b"}) {
+		t.Fatalf("unexpected bootstrap list %v", c.Bootstrap)
+	}
+	if !reflect.DeepEqual(c.Addresses.API, Strings{"/ip4/127.0.0.1/tcp/5002"}) {
+		t.Fatalf("unexpected API address %v", c.Addresses.API)
+	}
+	if c.Swarm.Transports.Network.QUIC != False || c.Swarm.Transports.Security.SECIO != Disabled {
+		t.Fatal("expected flag and priority overrides to be applied")
+	}
+	if c.AutoNAT.ServiceMode != AutoNATServiceDisabled || c.AutoNAT.Throttle == nil ||
+		time.Duration(c.AutoNAT.Throttle.Interval) != 2*time.Minute {
+		t.Fatalf("unexpected autonat config %+v", c.AutoNAT)
+	}
+	if time.Duration(c.Swarm.RelayService.ReservationTTL) != time.Hour || c.Swarm.RelayService.MaxReservations != 64 {
+		t.Fatalf("unexpected relay service %+v", c.Swarm.RelayService)
+	}
+	if c.UI.Host.ContractManager != nil {
+		t.Fatal("expected unset optional sections to stay nil")
+	}
+
+	env = map[string]string{"BTFS_SWARM_CONNMGR_HIGHWATER": "lots"}
+	if err := c.ApplyEnvOverrides(getenv); err == nil {
+		t.Fatal("expected type mismatch to fail")
+	}
+}
+
+func TestApplyEnvOverridesOptionalValues(t *testing.T) {
+	env := map[string]string{
+		"BTFS_IMPORT_CIDVERSION":             "1",
+		"BTFS_GATEWAY_FASTDIRINDEXTHRESHOLD": "100",
+		"BTFS_IPNS_MAXCACHETTL":              "1m",
+		"BTFS_DNS_MAXCACHETTL":               "30s",
+	}
+	getenv := func(k string) string { return env[k] }
+
+	c := new(Config)
+	if err := c.ApplyEnvOverrides(getenv); err != nil {
+		t.Fatal(err)
+	}
+	if c.Import.CidVersion == nil || *c.Import.CidVersion != 1 {
+		t.Fatalf("unexpected cid version %v", c.Import.CidVersion)
+	}
+	if c.Gateway.FastDirIndexThreshold == nil || *c.Gateway.FastDirIndexThreshold != 100 {
+		t.Fatalf("unexpected directory index threshold %v", c.Gateway.FastDirIndexThreshold)
+	}
+	if c.Ipns.MaxCacheTTL == nil || *c.Ipns.MaxCacheTTL != "1m" || c.DNS.MaxCacheTTL == nil || *c.DNS.MaxCacheTTL != "30s" {
+		t.Fatal("expected optional strings to be set")
+	}
+	if c.Internal.Bitswap != nil {
+		t.Fatal("expected unset optional values to stay nil")
+	}
+
+	env = map[string]string{"BTFS_IMPORT_CIDVERSION": "one"}
+	if err := c.ApplyEnvOverrides(getenv); err == nil {
+		t.Fatal("expected type mismatch to fail")
+	}
+	env = map[string]string{"BTFS_API_HTTPHEADERS": "X-Test"}
+	if err := c.ApplyEnvOverrides(getenv); err == nil {
+		t.Fatal("expected map override to be rejected")
+	}
+}
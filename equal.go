@@ -0,0 +1,122 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Equal reports whether the two address sets are the same. The order of the
+// addresses in each list doesn't matter, nor does an empty list differ from
+// a missing one.
+func (a Addresses) Equal(b Addresses) bool {
+	return sameStrings(a.Swarm, b.Swarm) &&
+		sameStrings(a.Announce, b.Announce) &&
+		sameStrings(a.AppendAnnounce, b.AppendAnnounce) &&
+		sameStrings(a.NoAnnounce, b.NoAnnounce) &&
+		sameStrings(a.API, b.API) &&
+		sameStrings(a.Gateway, b.Gateway) &&
+		sameStrings(a.RemoteAPI, b.RemoteAPI)
+}
+
+// Equal reports whether the two swarm configs are the same, ignoring the
+// order of Swarm.AddrFilters and Swarm.RelayClient.StaticRelays.
+func (s SwarmConfig) Equal(b SwarmConfig) bool {
+	if !sameStrings(s.AddrFilters, b.AddrFilters) || !sameStrings(s.RelayClient.StaticRelays, b.RelayClient.StaticRelays) {
+		return false
+	}
+	s.AddrFilters, b.AddrFilters = nil, nil
+	s.RelayClient.StaticRelays, b.RelayClient.StaticRelays = nil, nil
+	if len(s.ResourceMgr.Limits) == 0 && len(b.ResourceMgr.Limits) == 0 {
+		s.ResourceMgr.Limits, b.ResourceMgr.Limits = nil, nil
+	}
+	return reflect.DeepEqual(s, b)
+}
+
+// Equal reports whether the two gateway configs are the same, ignoring the
+// order of header values and of the path lists.
+func (g Gateway) Equal(b Gateway) bool {
+	if !sameHeaders(g.HTTPHeaders, b.HTTPHeaders) ||
+		!sameStrings(g.PathPrefixes, b.PathPrefixes) ||
+		!sameStrings(g.APICommands, b.APICommands) ||
+		len(g.PublicGateways) != len(b.PublicGateways) {
+		return false
+	}
+	for host, spec := range g.PublicGateways {
+		other, ok := b.PublicGateways[host]
+		if !ok || (spec == nil) != (other == nil) {
+			return false
+		}
+		if spec == nil {
+			continue
+		}
+		if !sameStrings(spec.Paths, other.Paths) {
+			return false
+		}
+		sc, oc := *spec, *other
+		sc.Paths, oc.Paths = nil, nil
+		if !reflect.DeepEqual(sc, oc) {
+			return false
+		}
+	}
+	g.HTTPHeaders, b.HTTPHeaders = nil, nil
+	g.PathPrefixes, b.PathPrefixes = nil, nil
+	g.APICommands, b.APICommands = nil, nil
+	g.PublicGateways, b.PublicGateways = nil, nil
+	return reflect.DeepEqual(g, b)
+}
+
+// Equal reports whether the two API configs are the same, ignoring the order
+// of header values and of the allowed paths of each authorization.
+func (a API) Equal(b API) bool {
+	if !sameHeaders(a.HTTPHeaders, b.HTTPHeaders) || len(a.Authorizations) != len(b.Authorizations) {
+		return false
+	}
+	for name, scope := range a.Authorizations {
+		other, ok := b.Authorizations[name]
+		if !ok || (scope == nil) != (other == nil) {
+			return false
+		}
+		if scope != nil && (scope.AuthSecret != other.AuthSecret || !sameStrings(scope.AllowedPaths, other.AllowedPaths)) {
+			return false
+		}
+	}
+	a.HTTPHeaders, b.HTTPHeaders = nil, nil
+	a.Authorizations, b.Authorizations = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// BootstrapEqual reports whether the two bootstrap lists hold the same peer
+// addresses, in any order.
+func BootstrapEqual(a, b []string) bool {
+	return sameStrings(a, b)
+}
+
+// sameStrings reports whether a and b hold the same strings, in any order.
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if len(a) == 0 {
+		return true
+	}
+	as := append([]string{}, a...)
+	bs := append([]string{}, b...)
+	sort.Strings(as)
+	sort.Strings(bs)
+	return reflect.DeepEqual(as, bs)
+}
+
+// sameHeaders reports whether a and b hold the same headers with the same
+// values, in any order.
+func sameHeaders(a, b map[string][]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		other, ok := b[k]
+		if !ok || !sameStrings(v, other) {
+			return false
+		}
+	}
+	return true
+}
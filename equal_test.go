@@ -0,0 +1,76 @@
+package config
+
+import "testing"
+
+func TestAddressesEqual(t *testing.T) {
+	a := addressesConfig()
+	b := addressesConfig()
+	b.Swarm[0], b.Swarm[1] = b.Swarm[1], b.Swarm[0]
+	b.Announce = nil
+	if !a.Equal(b) {
+		t.Fatal("expected reordered addresses to be equal")
+	}
+	b.NoAnnounce = []string{"/ip4/10.0.0.0/ipcidr/8"}
+	if a.Equal(b) {
+		t.Fatal("expected different NoAnnounce to differ")
+	}
+}
+
+func TestSwarmConfigEqual(t *testing.T) {
+	a := DefaultSwarmConfig()
+	a.AddrFilters = []string{"/ip4/10.0.0.0/ipcidr/8", "/ip4/192.168.0.0/ipcidr/16"}
+	b := DefaultSwarmConfig()
+	b.AddrFilters = []string{"/ip4/192.168.0.0/ipcidr/16", "/ip4/10.0.0.0/ipcidr/8"}
+	b.ResourceMgr.Limits = map[string]ResourceLimit{}
+	if !a.Equal(b) {
+		t.Fatal("expected reordered filters to be equal")
+	}
+	b.ConnMgr.HighWater++
+	if a.Equal(b) {
+		t.Fatal("expected different water marks to differ")
+	}
+}
+
+func TestGatewayEqual(t *testing.T) {
+	a := DefaultGateway()
+	a.PublicGateways = map[string]*GatewaySpec{"example.com": {Paths: []string{"/btfs", "/btns"}}}
+	b := DefaultGateway()
+	b.HTTPHeaders["Access-Control-Allow-Headers"] = []string{"User-Agent", "Range", "X-Requested-With"}
+	b.PublicGateways = map[string]*GatewaySpec{"example.com": {Paths: []string{"/btns", "/btfs"}}}
+	if !a.Equal(b) {
+		t.Fatal("expected reordered header values and paths to be equal")
+	}
+	b.PublicGateways["example.com"].UseSubdomains = true
+	if a.Equal(b) {
+		t.Fatal("expected different public gateway specs to differ")
+	}
+	b = DefaultGateway()
+	b.PublicGateways = a.PublicGateways
+	b.Writable = true
+	if a.Equal(b) {
+		t.Fatal("expected different Writable to differ")
+	}
+}
+
+func TestAPIEqual(t *testing.T) {
+	a := API{HTTPHeaders: map[string][]string{"Access-Control-Allow-Methods": {"GET", "POST"}}}
+	b := API{HTTPHeaders: map[string][]string{"Access-Control-Allow-Methods": {"POST", "GET"}}}
+	if !a.Equal(b) {
+		t.Fatal("expected reordered header values to be equal")
+	}
+	b.Authorizations = map[string]*RPCAuthScope{"admin": {AuthSecret: "bearer:token"}}
+	if a.Equal(b) {
+		t.Fatal("expected different authorizations to differ")
+	}
+}
+
+func TestBootstrapEqual(t *testing.T) {
+	a := DefaultBootstrapAddresses[:3]
+	b := []string{a[2], a[0], a[1]}
+	if !BootstrapEqual(a, b) {
+		t.Fatal("expected reordered bootstrap peers to be equal")
+	}
+	if BootstrapEqual(a, DefaultBootstrapAddresses[:2]) {
+		t.Fatal("expected different bootstrap peers to differ")
+	}
+}
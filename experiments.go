@@ -1,5 +1,10 @@
 package config
 
+import (
+	"reflect"
+	"strings"
+)
+
 type Experiments struct {
 	FilestoreEnabled     bool
 	UrlstoreEnabled      bool
@@ -18,3 +23,22 @@ type Experiments struct {
 	HostRepairEnabled    bool
 	HostChallengeEnabled bool
 }
+
+// Enabled reports whether the named experimental feature (e.g.
+// "FilestoreEnabled" or "Libp2pStreamMounting") is turned on. Names are
+// matched case insensitively, the "Enabled" suffix may be omitted, and
+// unknown features are reported as disabled.
+func (e Experiments) Enabled(name string) bool {
+	v := reflect.ValueOf(e)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type.Kind() != reflect.Bool {
+			continue
+		}
+		if strings.EqualFold(f.Name, name) || strings.EqualFold(f.Name, name+"Enabled") {
+			return v.Field(i).Bool()
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// configFields is Config without its JSON methods.
+type configFields Config
+
+// UnmarshalJSON decodes the config, keeping top level keys unknown to this
+// version in Extra so they are written back by MarshalJSON instead of being
+// silently dropped, e.g. when a node is downgraded.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*configFields)(c)); err != nil {
+		return err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for k, v := range raw {
+		if isConfigField(k) {
+			continue
+		}
+		if c.Extra == nil {
+			c.Extra = map[string]json.RawMessage{}
+		}
+		c.Extra[k] = v
+	}
+	return nil
+}
+
+// MarshalJSON encodes the config followed by the keys preserved in Extra,
+// in sorted order.
+func (c Config) MarshalJSON() ([]byte, error) {
+	out, err := json.Marshal(configFields(c))
+	if err != nil || len(c.Extra) == 0 {
+		return out, err
+	}
+
+	keys := make([]string, 0, len(c.Extra))
+	for k := range c.Extra {
+		if !isConfigField(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(out[:len(out)-1]) // drop the closing brace
+	for _, k := range keys {
+		name, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		value := c.Extra[k]
+		if len(value) == 0 {
+			value = json.RawMessage("null")
+		}
+		buf.WriteByte(',')
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MetadataPrefix starts top level keys holding user annotations, such as
+// "_comment". JSON has no comments, so operators annotate their config with
+// such keys; they are kept in Extra across Load and Store, ignored by
+// Validate and not reported by UnknownFields. Only top level keys are kept,
+// annotations nested inside a section are dropped.
+const MetadataPrefix = "_"
+
+// UnknownFields returns the sorted top level keys this version doesn't
+// know about, preserved in Extra. Metadata keys are left out.
+func (c *Config) UnknownFields() []string {
+	keys := make([]string, 0, len(c.Extra))
+	for k := range c.Extra {
+		if !strings.HasPrefix(k, MetadataPrefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Metadata returns the top level keys starting with MetadataPrefix and
+// their raw JSON values.
+func (c *Config) Metadata() map[string]json.RawMessage {
+	m := map[string]json.RawMessage{}
+	for k, v := range c.Extra {
+		if strings.HasPrefix(k, MetadataPrefix) {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// SetMetadata stores value, encoded as JSON, under the metadata key, which
+// must start with MetadataPrefix.
+func (c *Config) SetMetadata(key string, value interface{}) error {
+	if !strings.HasPrefix(key, MetadataPrefix) {
+		return fmt.Errorf("metadata key %q must start with %q", key, MetadataPrefix)
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if c.Extra == nil {
+		c.Extra = map[string]json.RawMessage{}
+	}
+	c.Extra[key] = raw
+	return nil
+}
+
+// isConfigField reports whether key decodes into a Config field. Like
+// encoding/json, the match is case insensitive.
+func isConfigField(key string) bool {
+	t := reflect.TypeOf(configFields{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+	return false
+}
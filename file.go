@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/facebookgo/atomicfile"
+)
+
+// Load reads the config file at path, migrates it to CurrentConfigVersion
+// and validates it.
+func Load(path string) (*Config, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := Decode(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Decode migrates the JSON config raw to CurrentConfigVersion, see Migrate,
+// and decodes it. Unlike Load, it doesn't validate the result.
+func Decode(raw []byte) (*Config, error) {
+	raw, _, err := Migrate(raw)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("failure to decode config: %s", err)
+	}
+	return &c, nil
+}
+
+// Store atomically writes c to path, readable by the owner only since the
+// config holds the node's private key. The previous file, if any, is left
+// untouched when writing fails.
+func Store(path string, c *Config) error {
+	return WriteFile(path, c)
+}
+
+// WriteFile atomically writes value, encoded like Marshal, to path as Store
+// does.
+func WriteFile(path string, value interface{}) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		buf, err := Marshal(value)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(buf)
+		return err
+	})
+}
+
+// writeFileAtomic creates path with the content written by write. The file
+// is only replaced once write succeeds, on error the temporary file is
+// removed.
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := atomicfile.New(path, 0600)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Abort()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestLoadStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "btfs-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "repo", DefaultConfigFile)
+
+	cfg, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Store(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Identity != cfg.Identity || loaded.Version != CurrentConfigVersion {
+		t.Fatal("expected config to round trip")
+	}
+
+	st, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" {
+		if perm := st.Mode().Perm(); perm != 0600 {
+			t.Fatalf("expected config file mode 0600, got %v", perm)
+		}
+	}
+
+	before, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertUnchanged := func(what string) {
+		t.Helper()
+		after, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(before) != string(after) {
+			t.Fatalf("%s modified the config file", what)
+		}
+		entries, err := ioutil.ReadDir(filepath.Dir(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Fatalf("%s: expected only the config file to be left behind, got %d entries", what, len(entries))
+		}
+	}
+
+	// a write failing once the temporary file holds part of the new config
+	// must not clobber the existing file.
+	err = writeFileAtomic(path, func(w io.Writer) error {
+		if _, err := w.Write([]byte(`{"Identity":`)); err != nil {
+			return err
+		}
+		if entries, _ := ioutil.ReadDir(filepath.Dir(path)); len(entries) != 2 {
+			t.Errorf("expected a temporary file next to the config, got %d entries", len(entries))
+		}
+		return errors.New("disk full")
+	})
+	if err == nil || err.Error() != "disk full" {
+		t.Fatalf("expected the write error, got %v", err)
+	}
+	assertUnchanged("failed write")
+
+	// neither must a value that can't be encoded.
+	cfg.Plugins.Plugins = map[string]Plugin{"bad": {Config: make(chan int)}}
+	if err := Store(path, cfg); err == nil {
+		t.Fatal("expected unencodable config to fail")
+	}
+	assertUnchanged("unencodable config")
+}
+
+func TestUnknownFieldsRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "btfs-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, DefaultConfigFile)
+
+	cfg, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.UnknownFields()) != 0 {
+		t.Fatalf("expected no unknown fields, got %v", cfg.UnknownFields())
+	}
+	buf, err := Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf, &m); err != nil {
+		t.Fatal(err)
+	}
+	m["FutureFeature"] = map[string]interface{}{"Enabled": true}
+	buf, err = json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, buf, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unknown := loaded.UnknownFields(); len(unknown) != 1 || unknown[0] != "FutureFeature" {
+		t.Fatalf("expected FutureFeature to be reported, got %v", unknown)
+	}
+	if err := Store(path, loaded); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var future bytes.Buffer
+	if err := json.Compact(&future, reloaded.Extra["FutureFeature"]); err != nil {
+		t.Fatal(err)
+	}
+	if future.String() != `{"Enabled":true}` {
+		t.Fatalf("expected FutureFeature to survive a round trip, got %s", future.String())
+	}
+	if reloaded.Identity != cfg.Identity {
+		t.Fatal("expected known fields to survive a round trip")
+	}
+
+	clone, err := reloaded.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clone.UnknownFields()) != 1 {
+		t.Fatal("expected Clone to keep unknown fields")
+	}
+}
+
+func TestMetadataRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "btfs-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, DefaultConfigFile)
+
+	cfg, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.SetMetadata("_note", "provisioned by ops"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.SetMetadata("note", "x"); err == nil {
+		t.Fatal("expected metadata key without prefix to be rejected")
+	}
+	if err := Store(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.UnknownFields()) != 0 {
+		t.Fatalf("expected metadata not to be reported as unknown, got %v", loaded.UnknownFields())
+	}
+	if note := string(loaded.Metadata()["_note"]); note != `"provisioned by ops"` {
+		t.Fatalf("expected _note to survive Load and Store, got %s", note)
+	}
+	if err := Store(path, loaded); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(raw, []byte(`"_note": "provisioned by ops"`)) {
+		t.Fatalf("expected _note in the stored file, got %s", raw)
+	}
+}
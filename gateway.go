@@ -1,5 +1,12 @@
 package config
 
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
 type GatewaySpec struct {
 	// Paths is explicit list of path prefixes that should be handled by
 	// this gateway. Example: `["/ipfs", "/ipns", "/api"]`
@@ -18,6 +25,12 @@ type GatewaySpec struct {
 	// NoDNSLink configures this gateway to _not_ resolve DNSLink for the FQDN
 	// provided in `Host` HTTP header.
 	NoDNSLink bool
+
+	// InlineDNSLink configures this gateway to always inline DNSLink names
+	// (FQDN) into a single DNS label in order to interop with wildcard TLS
+	// certs and Origin per CID isolation provided by rules like
+	// https://publicsuffix.org
+	InlineDNSLink Flag `json:",omitempty"`
 }
 
 // Gateway contains options for the HTTP gateway server.
@@ -27,8 +40,9 @@ type Gateway struct {
 	// gateway.
 	HTTPHeaders map[string][]string // HTTP headers to return with the gateway
 
-	// RootRedirect is the path to which requests to `/` on this gateway
-	// should be redirected.
+	// RootRedirect is the path or URL to which requests to `/` on this
+	// gateway should be redirected. It may contain RootRedirectCIDToken, see
+	// ResolveRootRedirect.
 	RootRedirect string
 
 	// Writable enables PUT/POST request handling by this gateway. Usually,
@@ -68,4 +82,114 @@ type Gateway struct {
 	// PublicGateways configures behavior of known public gateways.
 	// Each key is a fully qualified domain name (FQDN).
 	PublicGateways map[string]*GatewaySpec
+
+	// DeserializedResponses configures the gateway to reassemble files and
+	// directories. When disabled, only trustless responses (raw blocks and
+	// CAR files) are served. Defaults to on.
+	DeserializedResponses Flag `json:",omitempty"`
+
+	// ExposeRoutingAPI exposes the delegated routing HTTP API under
+	// /routing/v1. Defaults to off.
+	ExposeRoutingAPI Flag `json:",omitempty"`
+
+	// FastDirIndexThreshold is the number of directory entries above which
+	// directory listings skip fetching child sizes and types.
+	FastDirIndexThreshold *int `json:",omitempty"`
+
+	// ReadTimeout, WriteTimeout and IdleTimeout bound the HTTP server
+	// connections, e.g. "30s". Zero disables a timeout, see ServerTimeouts.
+	ReadTimeout  string `json:",omitempty"`
+	WriteTimeout string `json:",omitempty"`
+	IdleTimeout  string `json:",omitempty"`
+}
+
+// RootRedirectCIDToken is replaced in Gateway.RootRedirect by the CID passed
+// to ResolveRootRedirect.
+const RootRedirectCIDToken = "{cid}"
+
+// ResolveRootRedirect returns the redirect target for requests to `/`, with
+// every RootRedirectCIDToken replaced by defaultCID.
+func (g Gateway) ResolveRootRedirect(defaultCID string) string {
+	return strings.ReplaceAll(g.RootRedirect, RootRedirectCIDToken, defaultCID)
+}
+
+// validateRootRedirect checks that a non-empty redirect is an absolute path
+// other than `/` itself, or an absolute URL.
+func validateRootRedirect(redirect string) error {
+	if redirect == "" {
+		return nil
+	}
+	// the token isn't valid in a URL, check a placeholder CID instead.
+	target := strings.ReplaceAll(redirect, RootRedirectCIDToken, "cid")
+	if strings.HasPrefix(target, "/") {
+		if strings.HasPrefix(target, "//") {
+			return fieldErrorf("Gateway.RootRedirect", "%q is a scheme relative URL, use an absolute URL instead", redirect)
+		}
+		u, err := url.Parse(target)
+		if err != nil {
+			return fieldErrorf("Gateway.RootRedirect", "%s", err)
+		}
+		if u.Path == "/" {
+			return fieldErrorf("Gateway.RootRedirect", "%q redirects to itself", redirect)
+		}
+		return nil
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return fieldErrorf("Gateway.RootRedirect", "%s", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fieldErrorf("Gateway.RootRedirect", "%q must be an absolute path or URL", redirect)
+	}
+	return nil
+}
+
+// SpecFor returns the public gateway spec matching host, or nil if there is
+// none. Any port is ignored and an exact match wins over a wildcard entry
+// such as "*.example.com".
+func (g Gateway) SpecFor(host string) *GatewaySpec {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if spec, ok := g.PublicGateways[host]; ok {
+		return spec
+	}
+	for i := strings.IndexByte(host, '.'); i >= 0; i = strings.IndexByte(host, '.') {
+		host = host[i+1:]
+		if spec, ok := g.PublicGateways["*."+host]; ok {
+			return spec
+		}
+	}
+	return nil
+}
+
+// Validate checks the root redirect, the public gateway specs, the directory
+// listing threshold and the server timeouts.
+func (g Gateway) Validate() error {
+	var errs ValidationErrors
+	errs.add(validateRootRedirect(g.RootRedirect))
+	_, _, _, err := g.ServerTimeouts()
+	errs.add(err)
+	hosts := make([]string, 0, len(g.PublicGateways))
+	for host := range g.PublicGateways {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		spec := g.PublicGateways[host]
+		if spec == nil {
+			continue
+		}
+		for _, p := range spec.Paths {
+			if !strings.HasPrefix(p, "/") {
+				errs.add(fieldErrorf("Gateway.PublicGateways."+host+".Paths", "%q must start with /", p))
+			}
+		}
+	}
+	if g.FastDirIndexThreshold != nil && *g.FastDirIndexThreshold < 0 {
+		errs.add(fieldErrorf("Gateway.FastDirIndexThreshold", "must not be negative, got %d", *g.FastDirIndexThreshold))
+	}
+	return errs.errOrNil()
 }
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGatewaySpecFor(t *testing.T) {
+	local := &GatewaySpec{Paths: []string{"/btfs", "/btns"}}
+	sub := &GatewaySpec{Paths: []string{"/btfs"}, UseSubdomains: true}
+	exact := &GatewaySpec{Paths: []string{"/btfs"}, NoDNSLink: true}
+	g := Gateway{PublicGateways: map[string]*GatewaySpec{
+		"localhost":            local,
+		"*.dweb.example.com":   sub,
+		"www.dweb.example.com": exact,
+	}}
+
+	for host, expected := range map[string]*GatewaySpec{
+		"localhost:8080":        local,
+		"bafy.dweb.example.com": sub,
+		"a.b.dweb.example.com":  sub,
+		"WWW.dweb.example.com.": exact,
+		"dweb.example.com":      nil,
+		"example.org":           nil,
+	} {
+		if spec := g.SpecFor(host); spec != expected {
+			t.Errorf("%s: expected %v, got %v", host, expected, spec)
+		}
+	}
+
+	if err := g.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	g.PublicGateways["bad.example.com"] = &GatewaySpec{Paths: []string{"btfs"}}
+	if err := g.Validate(); err == nil {
+		t.Fatal("expected relative path to be rejected")
+	}
+}
+
+func TestGatewayHeaders(t *testing.T) {
+	var g Gateway
+	g.SetHeader("Access-Control-Allow-Origin", "*")
+	g.HTTPHeaders["access-control-allow-methods"] = []string{"GET"}
+
+	g.SetHeader("access-control-allow-origin", "https://example.com")
+	g.AddHeaderValue("Access-Control-Allow-Methods", "POST")
+	g.AddHeaderValue("ACCESS-CONTROL-ALLOW-METHODS", "GET")
+
+	expected := map[string][]string{
+		"Access-Control-Allow-Origin":  {"https://example.com"},
+		"Access-Control-Allow-Methods": {"GET", "POST"},
+	}
+	if !reflect.DeepEqual(g.HTTPHeaders, expected) {
+		t.Fatalf("expected headers %v, got %v", expected, g.HTTPHeaders)
+	}
+
+	var a API
+	a.AddHeaderValue("x-custom", "a")
+	a.AddHeaderValue("X-Custom", "a")
+	if !reflect.DeepEqual(a.HTTPHeaders, map[string][]string{"X-Custom": {"a"}}) {
+		t.Fatalf("unexpected API headers %v", a.HTTPHeaders)
+	}
+
+	g.SetHeader("access-control-allow-origin")
+	if _, ok := g.HTTPHeaders["Access-Control-Allow-Origin"]; ok || len(g.HTTPHeaders) != 1 {
+		t.Fatalf("expected an empty SetHeader to remove the header, got %v", g.HTTPHeaders)
+	}
+	var empty Gateway
+	if empty.SetHeader("X-Missing"); empty.HTTPHeaders != nil {
+		t.Fatal("expected removing from no headers to leave them unset")
+	}
+}
+
+func TestGatewayTrustless(t *testing.T) {
+	var g Gateway
+	if !g.DeserializedResponses.WithDefault(true) {
+		t.Fatal("expected deserialized responses to default to on")
+	}
+
+	threshold := 100
+	g.DeserializedResponses = False
+	g.ExposeRoutingAPI = True
+	g.FastDirIndexThreshold = &threshold
+	out, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Gateway
+	if err := json.Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.DeserializedResponses != False || back.ExposeRoutingAPI != True ||
+		back.FastDirIndexThreshold == nil || *back.FastDirIndexThreshold != 100 {
+		t.Fatalf("unexpected gateway after round trip: %s", out)
+	}
+	if err := back.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = json.Marshal(Gateway{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "DeserializedResponses") || strings.Contains(string(out), "FastDirIndexThreshold") {
+		t.Fatalf("expected unset fields to be omitted, got %s", out)
+	}
+
+	*back.FastDirIndexThreshold = -1
+	if err := back.Validate(); err == nil {
+		t.Fatal("expected negative FastDirIndexThreshold to be rejected")
+	}
+}
+
+func TestGatewayRootRedirect(t *testing.T) {
+	const cid = "QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn"
+	for redirect, expected := range map[string]string{
+		"":                              "",
+		"/btfs/" + RootRedirectCIDToken: "/btfs/" + cid,
+		"https://example.com/welcome":   "https://example.com/welcome",
+		"https://example.com/btfs/{cid}/index.html": "https://example.com/btfs/" + cid + "/index.html",
+	} {
+		g := Gateway{RootRedirect: redirect}
+		if err := g.Validate(); err != nil {
+			t.Errorf("%q: %s", redirect, err)
+		}
+		if got := g.ResolveRootRedirect(cid); got != expected {
+			t.Errorf("%q: expected %q, got %q", redirect, expected, got)
+		}
+	}
+
+	for _, redirect := range []string{"welcome", "/", "//example.com/", "example.com/welcome", "http://"} {
+		g := Gateway{RootRedirect: redirect}
+		if err := g.Validate(); err == nil {
+			t.Errorf("%q: expected redirect to be rejected", redirect)
+		}
+	}
+}
+
+func TestGatewaySecurityHeaders(t *testing.T) {
+	g := DefaultGateway()
+	g.NoDNSLink = true
+	cors := g.HTTPHeaders["Access-Control-Allow-Origin"]
+	for i := 0; i < 2; i++ {
+		g.ApplySecurityHeaders()
+	}
+	for name, values := range DefaultGatewaySecurityHeaders {
+		if !reflect.DeepEqual(g.HTTPHeaders[name], values) {
+			t.Errorf("expected %s to be %v, got %v", name, values, g.HTTPHeaders[name])
+		}
+	}
+	if !reflect.DeepEqual(g.HTTPHeaders["Access-Control-Allow-Origin"], cors) {
+		t.Fatalf("expected CORS headers to be kept, got %v", g.HTTPHeaders)
+	}
+
+	g = Gateway{HTTPHeaders: map[string][]string{"content-security-policy": {"sandbox"}}}
+	g.ApplySecurityHeaders()
+	if !reflect.DeepEqual(g.HTTPHeaders[ContentSecurityPolicyHeader], []string{"sandbox"}) || len(g.HTTPHeaders) != 2 {
+		t.Fatalf("expected the configured policy to be kept, got %v", g.HTTPHeaders)
+	}
+
+	out, err := json.Marshal(Gateway{NoDNSLink: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Gateway
+	if err := json.Unmarshal(out, &back); err != nil || !back.NoDNSLink {
+		t.Fatalf("expected NoDNSLink to round trip, got %s (%v)", out, err)
+	}
+}
@@ -1,12 +1,18 @@
 module github.com/TRON-US/go-btfs-config
 
 require (
+	github.com/btcsuite/btcd v0.20.1-beta
 	github.com/facebookgo/atomicfile v0.0.0-20151019160806-2de1f203e7d5
 	github.com/ipfs/go-cid v0.0.6 // indirect
 	github.com/libp2p/go-libp2p-core v0.6.0
 	github.com/mitchellh/go-homedir v1.1.0
+	github.com/mr-tron/base58 v1.1.3
 	github.com/multiformats/go-multiaddr v0.2.2
+	github.com/multiformats/go-multihash v0.0.13
 	github.com/tron-us/go-btfs-common v0.2.11
+	github.com/tyler-smith/go-bip39 v1.1.0
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 go 1.14
@@ -0,0 +1,132 @@
+package config
+
+import "net/textproto"
+
+// SetHeader replaces the values of the named gateway HTTP header. The name
+// is canonicalized, so "access-control-allow-origin" replaces
+// "Access-Control-Allow-Origin". Passing no values removes the header.
+func (g *Gateway) SetHeader(name string, values ...string) {
+	g.HTTPHeaders = setHeader(g.HTTPHeaders, name, values)
+}
+
+// AddHeaderValue adds value to the named gateway HTTP header unless it's
+// already present.
+func (g *Gateway) AddHeaderValue(name, value string) {
+	g.HTTPHeaders = addHeaderValue(g.HTTPHeaders, name, value)
+}
+
+// SetHeader replaces the values of the named API HTTP header. The name is
+// canonicalized like Gateway.SetHeader.
+func (a *API) SetHeader(name string, values ...string) {
+	a.HTTPHeaders = setHeader(a.HTTPHeaders, name, values)
+}
+
+// AddHeaderValue adds value to the named API HTTP header unless it's already
+// present.
+func (a *API) AddHeaderValue(name, value string) {
+	a.HTTPHeaders = addHeaderValue(a.HTTPHeaders, name, value)
+}
+
+// CORS response headers managed by API.SetCORS.
+const (
+	CORSAllowOriginHeader  = "Access-Control-Allow-Origin"
+	CORSAllowMethodsHeader = "Access-Control-Allow-Methods"
+	CORSAllowHeadersHeader = "Access-Control-Allow-Headers"
+)
+
+// SetCORS stores the allowed CORS origins, methods and headers of the API in
+// HTTPHeaders. An empty list removes the corresponding header.
+func (a *API) SetCORS(origins, methods, headers []string) {
+	for _, h := range []struct {
+		name   string
+		values []string
+	}{
+		{CORSAllowOriginHeader, origins},
+		{CORSAllowMethodsHeader, methods},
+		{CORSAllowHeadersHeader, headers},
+	} {
+		a.SetHeader(h.name, h.values...)
+	}
+}
+
+// CORS returns the allowed CORS origins, methods and headers set in the API
+// HTTPHeaders, whatever the spelling of the header names.
+func (a API) CORS() (origins, methods, headers []string) {
+	get := func(name string) []string {
+		for k, v := range a.HTTPHeaders {
+			if textproto.CanonicalMIMEHeaderKey(k) == name {
+				return appendSingle(nil, v)
+			}
+		}
+		return nil
+	}
+	return get(CORSAllowOriginHeader), get(CORSAllowMethodsHeader), get(CORSAllowHeadersHeader)
+}
+
+// Security response headers managed by Gateway.ApplySecurityHeaders.
+const (
+	ContentSecurityPolicyHeader = "Content-Security-Policy"
+	ContentTypeOptionsHeader    = "X-Content-Type-Options"
+)
+
+// DefaultGatewaySecurityHeaders are the values set by
+// Gateway.ApplySecurityHeaders. The policy keeps served content from loading
+// anything but itself, which is what a gateway not hosting DNSLink websites
+// needs.
+var DefaultGatewaySecurityHeaders = map[string][]string{
+	ContentSecurityPolicyHeader: {"default-src 'self'; frame-ancestors 'none'; base-uri 'self'"},
+	ContentTypeOptionsHeader:    {"nosniff"},
+}
+
+// ApplySecurityHeaders adds DefaultGatewaySecurityHeaders to the gateway
+// HTTPHeaders. Headers that are already set, whatever the spelling of their
+// name, are kept as is, as are the CORS headers, so calling it again changes
+// nothing.
+func (g *Gateway) ApplySecurityHeaders() {
+	if g.HTTPHeaders == nil {
+		g.HTTPHeaders = map[string][]string{}
+	}
+	for name, values := range DefaultGatewaySecurityHeaders {
+		key := canonicalizeHeader(g.HTTPHeaders, name)
+		if len(g.HTTPHeaders[key]) == 0 {
+			g.HTTPHeaders[key] = appendSingle(nil, values)
+		}
+	}
+}
+
+// canonicalizeHeader folds every spelling of name in headers into its
+// canonical key and returns that key.
+func canonicalizeHeader(headers map[string][]string, name string) string {
+	key := textproto.CanonicalMIMEHeaderKey(name)
+	for k, v := range headers {
+		if k != key && textproto.CanonicalMIMEHeaderKey(k) == key {
+			headers[key] = appendSingle(headers[key], v)
+			delete(headers, k)
+		}
+	}
+	return key
+}
+
+func setHeader(headers map[string][]string, name string, values []string) map[string][]string {
+	if len(values) == 0 {
+		if headers != nil {
+			delete(headers, canonicalizeHeader(headers, name))
+		}
+		return headers
+	}
+	if headers == nil {
+		headers = map[string][]string{}
+	}
+	key := canonicalizeHeader(headers, name)
+	headers[key] = appendSingle(nil, values)
+	return headers
+}
+
+func addHeaderValue(headers map[string][]string, name, value string) map[string][]string {
+	if headers == nil {
+		headers = map[string][]string{}
+	}
+	key := canonicalizeHeader(headers, name)
+	headers[key] = appendSingle(headers[key], []string{value})
+	return headers
+}
@@ -0,0 +1,75 @@
+package config
+
+import "sort"
+
+// UseIdentity makes the identity stored under alias in Identities the live
+// one, copying it to the top level Identity that the node actually uses. The
+// previously live identity is written back under its own alias first, so
+// changes made to the top level Identity, e.g. by EncryptIdentity, are kept.
+// Without an ActiveIdentity that alias is the one holding the same PeerID.
+//
+// A top level Identity that isn't stored under any alias would be lost by the
+// switch, so that is an error.
+func (c *Config) UseIdentity(alias string) error {
+	if _, ok := c.Identities[alias]; !ok {
+		return fieldErrorf("Identities", "unknown alias %q", alias)
+	}
+	if c.ActiveIdentity != "" {
+		c.syncActiveIdentity()
+	} else if c.Identity.PeerID != "" {
+		current := c.identityAlias(c.Identity.PeerID)
+		if current == "" {
+			return fieldErrorf("Identity", "%s has no alias, add it to Identities before switching", c.Identity.PeerID)
+		}
+		c.Identities[current] = c.Identity
+	}
+	c.Identity = c.Identities[alias]
+	c.ActiveIdentity = alias
+	return nil
+}
+
+// syncActiveIdentity stores the top level Identity under the active alias.
+func (c *Config) syncActiveIdentity() {
+	if c.ActiveIdentity == "" {
+		return
+	}
+	if _, ok := c.Identities[c.ActiveIdentity]; !ok {
+		return
+	}
+	c.Identities[c.ActiveIdentity] = c.Identity
+}
+
+// identityAlias returns the alias an identity with peerID is stored under,
+// or the empty string.
+func (c *Config) identityAlias(peerID string) string {
+	aliases := make([]string, 0, len(c.Identities))
+	for alias := range c.Identities {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		if c.Identities[alias].PeerID == peerID {
+			return alias
+		}
+	}
+	return ""
+}
+
+// validateIdentities checks that ActiveIdentity names a stored identity and
+// that the top level Identity is in sync with it.
+func (c *Config) validateIdentities() error {
+	var errs ValidationErrors
+	if _, ok := c.Identities[""]; ok {
+		errs.add(fieldErrorf("Identities", "alias must not be empty"))
+	}
+	if c.ActiveIdentity == "" {
+		return errs.errOrNil()
+	}
+	active, ok := c.Identities[c.ActiveIdentity]
+	if !ok {
+		errs.add(fieldErrorf("ActiveIdentity", "unknown alias %q", c.ActiveIdentity))
+	} else if active.PeerID != c.Identity.PeerID {
+		errs.add(fieldErrorf("Identity", "PeerID %s doesn't match active identity %q (%s)", c.Identity.PeerID, c.ActiveIdentity, active.PeerID))
+	}
+	return errs.errOrNil()
+}
@@ -1,9 +1,22 @@
 package config
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
 
 	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/scrypt"
 )
 
 const IdentityTag = "Identity"
@@ -12,7 +25,32 @@ const MnemonicTag = "Mnemonic"
 const PrivKeySelector = IdentityTag + "." + PrivKeyTag
 const MnemonicSelector = IdentityTag + "." + MnemonicTag
 
+// EncryptedPrivKeyPrefix marks an Identity.PrivKey value that has been
+// wrapped with a passphrase-derived key.
+const EncryptedPrivKeyPrefix = "enc:"
+
+// scrypt parameters used to derive the AES-256 key from a passphrase.
+const (
+	scryptN       = 1 << 15
+	scryptR       = 8
+	scryptP       = 1
+	scryptKeyLen  = 32
+	scryptSaltLen = 16
+)
+
+// ErrPassphraseRequired is returned when decoding an encrypted private key
+// without a passphrase.
+var ErrPassphraseRequired = errors.New("private key is encrypted, passphrase required")
+
+// ErrInvalidPassphrase is returned when an encrypted private key cannot be
+// opened with the given passphrase.
+var ErrInvalidPassphrase = errors.New("cannot decrypt private key: invalid passphrase or corrupted key")
+
 // Identity tracks the configuration of the local node's identity.
+//
+// Mnemonic, like PrivKey, is stored in plaintext: anyone reading the config
+// file can rebuild the key from it. EncryptIdentity moves it into
+// EncryptedMnemonic, see DecryptMnemonic.
 type Identity struct {
 	PeerID            string
 	PrivKey           string `json:",omitempty"`
@@ -21,14 +59,309 @@ type Identity struct {
 	EncryptedPrivKey  string `json:",omitempty"`
 }
 
-// DecodePrivateKey is a helper to decode the users PrivateKey
+// DecodePrivateKey is a helper to decode the users PrivateKey. passphrase is
+// only needed for encrypted keys, see DecryptIdentity.
 func (i *Identity) DecodePrivateKey(passphrase string) (ic.PrivKey, error) {
-	pkb, err := base64.StdEncoding.DecodeString(i.PrivKey)
+	return DecryptIdentity(*i, passphrase)
+}
+
+// HasIdentity reports whether the config holds a usable node identity: a
+// PeerID and a private key that decodes and matches it. Encrypted keys can't
+// be opened without the passphrase, so only their PeerID and envelope are
+// checked; for key references, see ResolvePrivateKey, only the PeerID is.
+func (c *Config) HasIdentity() bool {
+	ident := c.Identity
+	if ident.PeerID == "" || ident.PrivKey == "" {
+		return false
+	}
+	id, err := peer.Decode(ident.PeerID)
 	if err != nil {
-		return nil, err
+		return false
+	}
+	if ident.IsKeyReference() {
+		return true
 	}
+	if ident.IsEncrypted() {
+		sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ident.PrivKey, EncryptedPrivKeyPrefix))
+		return err == nil && len(sealed) > scryptSaltLen
+	}
+	sk, err := ident.DecodePrivateKey("")
+	if err != nil {
+		return false
+	}
+	return id.MatchesPrivateKey(sk)
+}
 
-	// currently storing key unencrypted. in the future we need to encrypt it.
-	// TODO(security)
+// HasPlaintextPrivateKey reports whether Identity.PrivKey holds a key that
+// isn't passphrase protected, letting provisioning tools enforce encryption.
+func (c *Config) HasPlaintextPrivateKey() bool {
+	ident := c.Identity
+	return ident.PrivKey != "" && !ident.IsEncrypted() && !ident.IsKeyReference()
+}
+
+// IsEncrypted reports whether the stored private key is passphrase protected.
+func (i *Identity) IsEncrypted() bool {
+	return strings.HasPrefix(i.PrivKey, EncryptedPrivKeyPrefix)
+}
+
+// EncryptIdentity wraps the plaintext private key of ident with an
+// scrypt-derived AES-GCM envelope and stores it back into ident.PrivKey,
+// prefixed with EncryptedPrivKeyPrefix. A mnemonic is sealed the same way
+// into ident.EncryptedMnemonic and cleared from ident.Mnemonic.
+func EncryptIdentity(ident *Identity, passphrase string) error {
+	if passphrase == "" {
+		return errors.New("cannot encrypt private key with an empty passphrase")
+	}
+	if ident.IsEncrypted() {
+		return errors.New("private key is already encrypted")
+	}
+	skbytes, err := base64.StdEncoding.DecodeString(ident.PrivKey)
+	if err != nil {
+		return err
+	}
+	sealed, err := sealPrivKey(skbytes, passphrase)
+	if err != nil {
+		return err
+	}
+	var sealedMnemonic []byte
+	if ident.Mnemonic != "" {
+		if sealedMnemonic, err = sealPrivKey([]byte(ident.Mnemonic), passphrase); err != nil {
+			return err
+		}
+		ident.EncryptedMnemonic = base64.StdEncoding.EncodeToString(sealedMnemonic)
+		ident.Mnemonic = ""
+	}
+	ident.PrivKey = EncryptedPrivKeyPrefix + base64.StdEncoding.EncodeToString(sealed)
+	return nil
+}
+
+// DecryptMnemonic returns the mnemonic of ident, opening EncryptedMnemonic
+// with passphrase when the plaintext one is unset. It returns the empty
+// string for identities without a mnemonic.
+func DecryptMnemonic(ident Identity, passphrase string) (string, error) {
+	if ident.Mnemonic != "" || ident.EncryptedMnemonic == "" {
+		return ident.Mnemonic, nil
+	}
+	if passphrase == "" {
+		return "", ErrPassphraseRequired
+	}
+	sealed, err := base64.StdEncoding.DecodeString(ident.EncryptedMnemonic)
+	if err != nil {
+		return "", err
+	}
+	mnemonic, err := openPrivKey(sealed, passphrase)
+	if err != nil {
+		return "", err
+	}
+	return string(mnemonic), nil
+}
+
+// DecryptIdentity decodes the private key of ident. Keys carrying the
+// EncryptedPrivKeyPrefix are decrypted with passphrase, all others are
+// treated as plain base64 for compatibility with existing configs.
+func DecryptIdentity(ident Identity, passphrase string) (ic.PrivKey, error) {
+	if !ident.IsEncrypted() {
+		pkb, err := base64.StdEncoding.DecodeString(ident.PrivKey)
+		if err != nil {
+			return nil, err
+		}
+		return ic.UnmarshalPrivateKey(pkb)
+	}
+
+	if passphrase == "" {
+		return nil, ErrPassphraseRequired
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ident.PrivKey, EncryptedPrivKeyPrefix))
+	if err != nil {
+		return nil, err
+	}
+	pkb, err := openPrivKey(sealed, passphrase)
+	if err != nil {
+		return nil, err
+	}
 	return ic.UnmarshalPrivateKey(pkb)
 }
+
+// sealPrivKey encrypts plaintext and returns salt || nonce || ciphertext.
+func sealPrivKey(plaintext []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, scryptSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := newKeyCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append(salt, nonce...)
+	return gcm.Seal(out, nonce, plaintext, nil), nil
+}
+
+// openPrivKey reverses sealPrivKey.
+func openPrivKey(sealed []byte, passphrase string) ([]byte, error) {
+	if len(sealed) < scryptSaltLen {
+		return nil, ErrInvalidPassphrase
+	}
+	salt, rest := sealed[:scryptSaltLen], sealed[scryptSaltLen:]
+	gcm, err := newKeyCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < gcm.NonceSize() {
+		return nil, ErrInvalidPassphrase
+	}
+	nonce, ciphertext := rest[:gcm.NonceSize()], rest[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrInvalidPassphrase
+	}
+	return plaintext, nil
+}
+
+func newKeyCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// RotateIdentity replaces the node identity with a freshly generated keypair
+// and returns the previous PeerID so callers can record it. When the current
+// key is encrypted, WithPassphrase must be given with the current passphrase
+// and the new key is encrypted with it as well.
+func (c *Config) RotateIdentity(out io.Writer, nbits int, keyType string, opts ...InitOption) (oldPeerID string, err error) {
+	var o initOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if c.Identity.IsEncrypted() {
+		if o.passphrase == "" {
+			return "", ErrPassphraseRequired
+		}
+		if _, err := DecryptIdentity(c.Identity, o.passphrase); err != nil {
+			return "", err
+		}
+	}
+
+	ident, err := identityConfig(out, nbits, keyType, "", "", o.generator())
+	if err != nil {
+		return "", err
+	}
+	if o.passphrase != "" {
+		if err := EncryptIdentity(&ident, o.passphrase); err != nil {
+			return "", err
+		}
+	}
+
+	oldPeerID = c.Identity.PeerID
+	c.Identity = ident
+	c.syncActiveIdentity()
+	return oldPeerID, nil
+}
+
+// PublicIdentity is the shareable part of an Identity.
+type PublicIdentity struct {
+	PeerID string
+	PubKey string // base64 encoded, marshaled libp2p public key
+}
+
+// PublicKey reconstructs the public key from the stored private key. For
+// encrypted keys it falls back to the key inlined in the PeerID, if any.
+func (i Identity) PublicKey() (ic.PubKey, error) {
+	if i.PrivKey == "" {
+		return nil, errors.New("identity has no private key")
+	}
+	if i.IsEncrypted() {
+		id, err := peer.Decode(i.PeerID)
+		if err != nil {
+			return nil, ErrPassphraseRequired
+		}
+		pk, err := id.ExtractPublicKey()
+		if err != nil || pk == nil {
+			return nil, ErrPassphraseRequired
+		}
+		return pk, nil
+	}
+	sk, err := DecryptIdentity(i, "")
+	if err != nil {
+		return nil, fmt.Errorf("malformed private key: %s", err)
+	}
+	return sk.GetPublic(), nil
+}
+
+// ExportPublic returns the PeerID and public key of the identity, without
+// any private key material.
+func (i Identity) ExportPublic() (PublicIdentity, error) {
+	pk, err := i.PublicKey()
+	if err != nil {
+		return PublicIdentity{}, err
+	}
+	pkb, err := ic.MarshalPublicKey(pk)
+	if err != nil {
+		return PublicIdentity{}, err
+	}
+	id, err := peer.IDFromPublicKey(pk)
+	if err != nil {
+		return PublicIdentity{}, err
+	}
+	return PublicIdentity{
+		PeerID: id.Pretty(),
+		PubKey: base64.StdEncoding.EncodeToString(pkb),
+	}, nil
+}
+
+// Fingerprint returns the SHA-256 hash of the marshaled public key as upper
+// case hex, in space separated groups of four characters, for out-of-band
+// identity verification.
+func (i Identity) Fingerprint() (string, error) {
+	sum, err := i.fingerprintHash()
+	if err != nil {
+		return "", err
+	}
+	h := strings.ToUpper(hex.EncodeToString(sum))
+	groups := make([]string, 0, len(h)/4)
+	for j := 0; j < len(h); j += 4 {
+		groups = append(groups, h[j:j+4])
+	}
+	return strings.Join(groups, " "), nil
+}
+
+// FingerprintWords returns the leading 66 bits of the fingerprint as six
+// words of the BIP39 english word list, easier to compare verbally.
+func (i Identity) FingerprintWords() (string, error) {
+	sum, err := i.fingerprintHash()
+	if err != nil {
+		return "", err
+	}
+	list := bip39.GetWordList()
+	bits := new(big.Int).SetBytes(sum)
+	words := make([]string, 6)
+	for j := range words {
+		// take 11 bits at a time, most significant first.
+		shift := uint(len(sum)*8 - 11*(j+1))
+		idx := new(big.Int).Rsh(bits, shift).Int64() & 0x7ff
+		words[j] = list[idx]
+	}
+	return strings.Join(words, " "), nil
+}
+
+func (i Identity) fingerprintHash() ([]byte, error) {
+	pk, err := i.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	pkb, err := ic.MarshalPublicKey(pk)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(pkb)
+	return sum[:], nil
+}
@@ -0,0 +1,443 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestEncryptedIdentity(t *testing.T) {
+	cfg, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false, WithPassphrase("hunter2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(cfg.Identity.PrivKey, EncryptedPrivKeyPrefix) {
+		t.Fatalf("expected encrypted private key, got %s", cfg.Identity.PrivKey)
+	}
+
+	// reload from json
+	buf, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded Config
+	if err := json.Unmarshal(buf, &loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	sk, err := DecryptIdentity(loaded.Identity, "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Pretty() != cfg.Identity.PeerID {
+		t.Fatalf("expected peer id %s, got %s", cfg.Identity.PeerID, id.Pretty())
+	}
+
+	if _, err := DecryptIdentity(loaded.Identity, "wrong"); err != ErrInvalidPassphrase {
+		t.Fatalf("expected ErrInvalidPassphrase, got %v", err)
+	}
+	if _, err := DecryptIdentity(loaded.Identity, ""); err != ErrPassphraseRequired {
+		t.Fatalf("expected ErrPassphraseRequired, got %v", err)
+	}
+}
+
+func TestPlaintextIdentity(t *testing.T) {
+	ident, err := IdentityConfig(ioutil.Discard, 2048, "Ed25519", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, err := ident.DecodePrivateKey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Pretty() != ident.PeerID {
+		t.Fatalf("expected peer id %s, got %s", ident.PeerID, id.Pretty())
+	}
+}
+
+func TestDeriveBip32Key(t *testing.T) {
+	// BIP32 test vector 1, chain m/0'/1
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	key, err := deriveBip32Key(seed, []uint32{0 + bip32HardenedOffset, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"
+	if hex.EncodeToString(key) != expected {
+		t.Fatalf("expected %s, got %x", expected, key)
+	}
+}
+
+func TestIdentityFromMnemonic(t *testing.T) {
+	// the well known BIP39 test mnemonic and the TRON account wallets derive
+	// from it.
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	const (
+		tronKey     = "b5a4cea271ff424d7c31dc12a3e43e401df7a40d7412a15750f3f0b6b5449a28"
+		tronAddress = "TUEZSdKsoDHQMeZwihtdoBiN46zxhGWYdH"
+	)
+	ident, err := IdentityFromMnemonic(ioutil.Discard, mnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr, err := ident.TronAddress(); err != nil || addr != tronAddress {
+		t.Fatalf("expected TRON address %s, got %s (%v)", tronAddress, addr, err)
+	}
+	imported, err := IdentityConfig(ioutil.Discard, 2048, "", tronKey, mnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ident != imported {
+		t.Fatalf("expected identity %+v, got %+v", imported, ident)
+	}
+
+	if err := EncryptIdentity(&ident, "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	if ident.Mnemonic != "" || ident.EncryptedMnemonic == "" || strings.Contains(ident.EncryptedMnemonic, "abandon") {
+		t.Fatalf("expected the mnemonic to be encrypted, got %+v", ident)
+	}
+	if _, err := DecryptMnemonic(ident, ""); err != ErrPassphraseRequired {
+		t.Fatalf("expected ErrPassphraseRequired, got %v", err)
+	}
+	if _, err := DecryptMnemonic(ident, "wrong"); err != ErrInvalidPassphrase {
+		t.Fatalf("expected ErrInvalidPassphrase, got %v", err)
+	}
+	if got, err := DecryptMnemonic(ident, "hunter2"); err != nil || got != mnemonic {
+		t.Fatalf("expected mnemonic to decrypt, got %q (%v)", got, err)
+	}
+
+	for _, bad := range []string{
+		"abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+	} {
+		if _, err := IdentityFromMnemonic(ioutil.Discard, bad, ""); !errors.Is(err, ErrInvalidMnemonic) {
+			t.Fatalf("expected ErrInvalidMnemonic for %q, got %v", bad, err)
+		}
+	}
+}
+
+func TestRotateIdentity(t *testing.T) {
+	cfg, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false, WithPassphrase("hunter2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := cfg.Identity
+
+	if _, err := cfg.RotateIdentity(ioutil.Discard, 2048, "Ed25519"); err != ErrPassphraseRequired {
+		t.Fatalf("expected ErrPassphraseRequired, got %v", err)
+	}
+	if _, err := cfg.RotateIdentity(ioutil.Discard, 2048, "Ed25519", WithPassphrase("wrong")); err != ErrInvalidPassphrase {
+		t.Fatalf("expected ErrInvalidPassphrase, got %v", err)
+	}
+	if cfg.Identity != orig {
+		t.Fatal("failed rotation modified the identity")
+	}
+
+	old, err := cfg.RotateIdentity(ioutil.Discard, 2048, "Ed25519", WithPassphrase("hunter2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old != orig.PeerID {
+		t.Fatalf("expected old peer id %s, got %s", orig.PeerID, old)
+	}
+	if cfg.Identity.PeerID == old {
+		t.Fatal("expected peer id to change")
+	}
+	sk, err := cfg.Identity.DecodePrivateKey("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Pretty() != cfg.Identity.PeerID {
+		t.Fatalf("expected peer id %s, got %s", cfg.Identity.PeerID, id.Pretty())
+	}
+}
+
+func TestExportPublic(t *testing.T) {
+	ident, err := IdentityConfig(ioutil.Discard, 2048, "Secp256k1", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ident.ExportPublic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub.PeerID != ident.PeerID {
+		t.Fatalf("expected peer id %s, got %s", ident.PeerID, pub.PeerID)
+	}
+	pkb, err := base64.StdEncoding.DecodeString(pub.PubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, err := ic.UnmarshalPublicKey(pkb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, err := ident.DecodePrivateKey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.Equals(sk.GetPublic()) {
+		t.Fatal("exported public key does not match the private key")
+	}
+
+	if err := EncryptIdentity(&ident, "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	encPub, err := ident.ExportPublic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encPub != pub {
+		t.Fatal("expected encrypted identity to export the same public identity")
+	}
+
+	if _, err := (Identity{}).ExportPublic(); err == nil {
+		t.Fatal("expected empty identity to fail")
+	}
+	if _, err := (Identity{PrivKey: "not base64!"}).ExportPublic(); err == nil {
+		t.Fatal("expected malformed identity to fail")
+	}
+}
+
+func TestIdentityConfigKeyTypes(t *testing.T) {
+	for _, tc := range []struct {
+		keyType string
+		nbits   int
+		log     string
+	}{
+		{"RSA", 2048, "generating 2048-bit RSA keypair..."},
+		{"Ed25519", 0, "generating Ed25519 keypair..."},
+		{"Secp256k1", 0, "generating Secp256k1 keypair..."},
+		{"ECDSA", 0, "generating ECDSA keypair..."},
+		{"", 0, "generating Secp256k1 keypair..."},
+	} {
+		var out bytes.Buffer
+		ident, err := IdentityConfig(&out, tc.nbits, tc.keyType, "", "")
+		if err != nil {
+			t.Fatalf("%s: %s", tc.keyType, err)
+		}
+		if !strings.HasPrefix(out.String(), tc.log) {
+			t.Fatalf("%s: expected log %q, got %q", tc.keyType, tc.log, out.String())
+		}
+		sk, err := ident.DecodePrivateKey("")
+		if err != nil {
+			t.Fatalf("%s: %s", tc.keyType, err)
+		}
+		id, err := peer.IDFromPrivateKey(sk)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.keyType, err)
+		}
+		if id.Pretty() != ident.PeerID {
+			t.Fatalf("%s: expected peer id %s, got %s", tc.keyType, ident.PeerID, id.Pretty())
+		}
+	}
+
+	if _, err := IdentityConfig(ioutil.Discard, 1024, "RSA", "", ""); err != ic.ErrRsaKeyTooSmall {
+		t.Fatalf("expected small RSA key to be rejected, got %v", err)
+	}
+}
+
+func TestIdentityConfigInvalidImportKey(t *testing.T) {
+	for _, key := range []string{
+		"zz",
+		"00",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141",
+	} {
+		if _, err := IdentityConfig(ioutil.Discard, 0, "", key, ""); err == nil {
+			t.Fatalf("expected import key %s to be rejected", key)
+		}
+	}
+
+	ident, err := IdentityConfig(ioutil.Discard, 0, "", "b5a4cea271ff424d7c31dc12a3e43e401df7a40d7412a15750f3f0b6b5449a28", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ident.PeerID == "" {
+		t.Fatal("expected imported identity to have a peer id")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	key := "b5a4cea271ff424d7c31dc12a3e43e401df7a40d7412a15750f3f0b6b5449a28"
+	ident, err := IdentityConfig(ioutil.Discard, 0, "", key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp, err := ident.Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	words, err := ident.FingerprintWords()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := IdentityConfig(ioutil.Discard, 0, "", key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp2, _ := again.Fingerprint(); fp2 != fp {
+		t.Fatalf("expected stable fingerprint %s, got %s", fp, fp2)
+	}
+	if words2, _ := again.FingerprintWords(); words2 != words {
+		t.Fatalf("expected stable fingerprint words %s, got %s", words, words2)
+	}
+	if len(strings.Fields(fp)) != 16 || len(strings.Fields(words)) != 6 {
+		t.Fatalf("unexpected fingerprint format %q / %q", fp, words)
+	}
+
+	other, err := IdentityConfig(ioutil.Discard, 0, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp3, _ := other.Fingerprint(); fp3 == fp {
+		t.Fatal("expected a different key to have a different fingerprint")
+	}
+}
+
+func TestHasIdentity(t *testing.T) {
+	c := NewDefault()
+	if c.HasIdentity() {
+		t.Fatal("expected an empty identity to be unusable")
+	}
+
+	ident, err := IdentityConfig(ioutil.Discard, 0, "Ed25519", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Identity = ident
+	if !c.HasIdentity() {
+		t.Fatal("expected a generated identity to be usable")
+	}
+
+	other, err := IdentityConfig(ioutil.Discard, 0, "Ed25519", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []Identity{
+		{PeerID: ident.PeerID, PrivKey: "not base64!"},
+		{PeerID: ident.PeerID, PrivKey: base64.StdEncoding.EncodeToString([]byte("garbage"))},
+		{PeerID: ident.PeerID, PrivKey: other.PrivKey},
+		{PeerID: "", PrivKey: ident.PrivKey},
+	} {
+		c.Identity = bad
+		if c.HasIdentity() {
+			t.Fatalf("expected identity %+v to be unusable", bad)
+		}
+	}
+
+	c.Identity = ident
+	if err := EncryptIdentity(&c.Identity, "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	if !c.HasIdentity() {
+		t.Fatal("expected an encrypted identity to be usable")
+	}
+}
+
+func TestHasPlaintextPrivateKey(t *testing.T) {
+	c := NewDefault()
+	if c.HasPlaintextPrivateKey() {
+		t.Fatal("expected an empty key not to count as plaintext")
+	}
+
+	ident, err := IdentityConfig(ioutil.Discard, 0, "Ed25519", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Identity = ident
+	if !c.HasPlaintextPrivateKey() {
+		t.Fatal("expected a generated key to be plaintext")
+	}
+
+	if err := EncryptIdentity(&c.Identity, "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	if c.HasPlaintextPrivateKey() {
+		t.Fatal("expected an encrypted key not to be plaintext")
+	}
+}
+
+func TestUseIdentity(t *testing.T) {
+	cfg, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vanity, err := IdentityConfig(ioutil.Discard, 2048, "Ed25519", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	main := cfg.Identity
+
+	cfg.Identities = map[string]Identity{"vanity": vanity}
+	if err := cfg.UseIdentity("vanity"); err == nil {
+		t.Fatal("expected switching away from an unaliased identity to fail")
+	}
+	cfg.Identities["main"] = main
+
+	// edits made before the first switch are kept.
+	if err := EncryptIdentity(&cfg.Identity, "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	main = cfg.Identity
+	if err := cfg.UseIdentity("vanity"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Identity != vanity || cfg.ActiveIdentity != "vanity" {
+		t.Fatalf("expected vanity identity to be live, got %s (%q)", cfg.Identity.PeerID, cfg.ActiveIdentity)
+	}
+	if stored := cfg.Identities["main"]; !stored.IsEncrypted() {
+		t.Fatal("expected the encrypted main identity to replace the plaintext copy")
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EncryptIdentity(&cfg.Identity, "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.UseIdentity("main"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Identity != main || cfg.ActiveIdentity != "main" {
+		t.Fatalf("expected main identity to be live, got %s (%q)", cfg.Identity.PeerID, cfg.ActiveIdentity)
+	}
+	if stored := cfg.Identities["vanity"]; !stored.IsEncrypted() {
+		t.Fatal("expected changes to the vanity identity to be kept")
+	}
+
+	if err := cfg.UseIdentity("nope"); err == nil {
+		t.Fatal("expected unknown alias to be rejected")
+	}
+	cfg.Identity = vanity
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected out of sync identity to be rejected")
+	}
+	cfg.Identity = main
+	cfg.ActiveIdentity = "nope"
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected unknown active identity to be rejected")
+	}
+}
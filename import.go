@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	mh "github.com/multiformats/go-multihash"
+)
+
+// Defaults used by `btfs add` when the Import section leaves a field unset.
+const (
+	DefaultCidVersion      = 0
+	DefaultUnixFSChunker   = "size-262144"
+	DefaultHashFunction    = "sha2-256"
+	DefaultBatchMaxNodes   = 128
+	DefaultBatchMaxSize    = 100 << 20 // 100MiB
+	DefaultUnixFSRawLeaves = false
+)
+
+// Import configures how data is chunked and hashed when added, so that every
+// node of a deployment produces the same CIDs for the same content.
+type Import struct {
+	CidVersion      *int   `json:",omitempty"`
+	UnixFSRawLeaves Flag   `json:",omitempty"`
+	UnixFSChunker   string `json:",omitempty"`
+	HashFunction    string `json:",omitempty"`
+
+	// BatchMaxNodes and BatchMaxSize bound the number of nodes and bytes
+	// buffered before a batch is committed to the datastore.
+	BatchMaxNodes int `json:",omitempty"`
+	BatchMaxSize  int `json:",omitempty"`
+}
+
+// DefaultImportConfig returns the import settings matching the `btfs add`
+// defaults.
+func DefaultImportConfig() Import {
+	cidVersion := DefaultCidVersion
+	return Import{
+		CidVersion:      &cidVersion,
+		UnixFSRawLeaves: False,
+		UnixFSChunker:   DefaultUnixFSChunker,
+		HashFunction:    DefaultHashFunction,
+		BatchMaxNodes:   DefaultBatchMaxNodes,
+		BatchMaxSize:    DefaultBatchMaxSize,
+	}
+}
+
+// Validate checks the CID version, chunker, hash function and batch limits.
+func (i Import) Validate() error {
+	var errs ValidationErrors
+	if i.CidVersion != nil && *i.CidVersion != 0 && *i.CidVersion != 1 {
+		errs.add(fieldErrorf("Import.CidVersion", "must be 0 or 1, got %d", *i.CidVersion))
+	}
+	if i.UnixFSChunker != "" {
+		if err := validateChunker(i.UnixFSChunker); err != nil {
+			errs.add(fieldErrorf("Import.UnixFSChunker", "%s", err))
+		}
+	}
+	if i.HashFunction != "" {
+		if _, ok := mh.Names[strings.ToLower(i.HashFunction)]; !ok {
+			errs.add(fieldErrorf("Import.HashFunction", "unknown multihash function %q", i.HashFunction))
+		}
+	}
+	if i.BatchMaxNodes < 0 || i.BatchMaxSize < 0 {
+		errs.add(fieldErrorf("Import", "batch limits must not be negative, got %d nodes and %d bytes", i.BatchMaxNodes, i.BatchMaxSize))
+	}
+	return errs.errOrNil()
+}
+
+// validateChunker accepts "size-<bytes>", "rabin", "rabin-<avg>",
+// "rabin-<min>-<avg>-<max>" and "buzhash".
+func validateChunker(chunker string) error {
+	parts := strings.Split(chunker, "-")
+	sizes := parts[1:]
+	switch parts[0] {
+	case "buzhash":
+		if len(sizes) != 0 {
+			return fmt.Errorf("buzhash chunker %q takes no parameters", chunker)
+		}
+		return nil
+	case "size":
+		if len(sizes) != 1 {
+			return fmt.Errorf("size chunker %q must be size-<bytes>", chunker)
+		}
+	case "rabin":
+		if len(sizes) != 0 && len(sizes) != 1 && len(sizes) != 3 {
+			return fmt.Errorf("rabin chunker %q must be rabin-<avg> or rabin-<min>-<avg>-<max>", chunker)
+		}
+	default:
+		return fmt.Errorf("unknown chunker %q", chunker)
+	}
+	for _, s := range sizes {
+		if n, err := strconv.Atoi(s); err != nil || n <= 0 {
+			return fmt.Errorf("invalid chunk size %q in %q", s, chunker)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestImportValidate(t *testing.T) {
+	if err := DefaultImportConfig().Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Import{}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, chunker := range []string{"size-1048576", "rabin", "rabin-262144", "rabin-128-262144-524288", "buzhash"} {
+		if err := (Import{UnixFSChunker: chunker}).Validate(); err != nil {
+			t.Fatalf("%s: %s", chunker, err)
+		}
+	}
+	for _, chunker := range []string{"size", "size-0", "size-big", "rabin-1-2", "buzhash-12", "fixed-1024"} {
+		if err := (Import{UnixFSChunker: chunker}).Validate(); err == nil {
+			t.Fatalf("expected chunker %q to be rejected", chunker)
+		}
+	}
+
+	v := 2
+	i := Import{CidVersion: &v, HashFunction: "md5-please", BatchMaxNodes: -1}
+	if errs, ok := i.Validate().(ValidationErrors); !ok || len(errs) != 3 {
+		t.Fatalf("expected three validation errors, got %v", i.Validate())
+	}
+	if err := (Import{HashFunction: "blake2b-256"}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportMarshal(t *testing.T) {
+	out, err := json.Marshal(Import{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "{}" {
+		t.Fatalf("expected empty import section to marshal to {}, got %s", out)
+	}
+
+	out, err = json.Marshal(DefaultImportConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Import
+	if err := json.Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.CidVersion == nil || *back.CidVersion != DefaultCidVersion || back.UnixFSChunker != DefaultUnixFSChunker {
+		t.Fatalf("unexpected import section after round trip: %s", out)
+	}
+}
@@ -6,95 +6,323 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
+	"strings"
 	"time"
 
 	hubpb "github.com/tron-us/go-btfs-common/protos/hub"
 
+	"github.com/btcsuite/btcd/btcec"
 	ci "github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/libp2p/go-libp2p-core/peer"
+	mh "github.com/multiformats/go-multihash"
 )
 
-func Init(out io.Writer, nBitsForKeypair int, keyType string, importKey string, mnemonic string, rmOnUnpin bool) (*Config, error) {
-	identity, err := IdentityConfig(out, nBitsForKeypair, keyType, importKey, mnemonic)
-	if err != nil {
-		return nil, err
+// InitOption customizes the config generated by Init.
+type InitOption func(*initOptions)
+
+type initOptions struct {
+	passphrase string
+
+	importMnemonic     string
+	mnemonicPassphrase string
+
+	keyFile string
+
+	rand         io.Reader
+	keyGenerator KeyGenerator
+
+	disableQUIC bool
+
+	profiles []string
+
+	bootstrap       []string
+	customBootstrap bool
+	offline         bool
+
+	cidV1Hash string
+}
+
+// WithPassphrase encrypts the generated private key with the given
+// passphrase. See EncryptIdentity.
+func WithPassphrase(passphrase string) InitOption {
+	return func(o *initOptions) {
+		o.passphrase = passphrase
 	}
+}
 
-	bootstrapPeers, err := DefaultBootstrapPeers()
-	if err != nil {
-		return nil, err
+// WithImportMnemonic derives the node key from a BIP39 mnemonic phrase instead
+// of generating one. See IdentityFromMnemonic.
+func WithImportMnemonic(mnemonic string, passphrase string) InitOption {
+	return func(o *initOptions) {
+		o.importMnemonic = mnemonic
+		o.mnemonicPassphrase = passphrase
 	}
+}
 
-	datastore := DefaultDatastoreConfig()
+// WithKeyFile imports the node key from a libp2p serialized private key file
+// instead of generating one. See IdentityFromKeyBytes.
+func WithKeyFile(path string) InitOption {
+	return func(o *initOptions) {
+		o.keyFile = path
+	}
+}
 
-	conf := &Config{
-		API: API{
-			HTTPHeaders: map[string][]string{},
-		},
+// WithRand makes key generation read its randomness from r instead of
+// crypto/rand, e.g. to provision nodes from a seed. Ed25519 and Secp256k1 keys
+// are then reproducible; RSA and ECDSA keys are not, the standard library
+// deliberately randomizes their generation.
+func WithRand(r io.Reader) InitOption {
+	return func(o *initOptions) {
+		o.rand = r
+	}
+}
 
-		// setup the node's default addresses.
-		// NOTE: two swarm listen addrs, one tcp, one utp.
-		Addresses: addressesConfig(),
-
-		Datastore: datastore,
-		Bootstrap: BootstrapPeerStrings(bootstrapPeers),
-		Identity:  identity,
-		Discovery: Discovery{
-			MDNS: MDNS{
-				Enabled:  true,
-				Interval: 10,
-			},
-		},
+// WithQUIC controls whether the default swarm addresses include QUIC
+// listeners. QUIC is enabled by default.
+func WithQUIC(enabled bool) InitOption {
+	return func(o *initOptions) {
+		o.disableQUIC = !enabled
+	}
+}
 
-		Routing: Routing{
-			Type: "dht",
-		},
+// WithProfiles applies the named profiles, in order, once the base config
+// has been built. InitOnly profiles are allowed here.
+func WithProfiles(names ...string) InitOption {
+	return func(o *initOptions) {
+		o.profiles = append(o.profiles, names...)
+	}
+}
 
-		// setup the node mount points.
-		Mounts: Mounts{
-			IPFS: "/btfs",
-			IPNS: "/btns",
-		},
+// WithBootstrap replaces the default bootstrap peers with addrs. Every
+// address must be a valid peer multiaddr. An empty list leaves the node
+// without bootstrap peers.
+func WithBootstrap(addrs []string) InitOption {
+	return func(o *initOptions) {
+		o.bootstrap = append([]string{}, addrs...)
+		o.customBootstrap = true
+	}
+}
 
-		Ipns: Ipns{
-			ResolveCacheSize: 128,
-		},
+// NewDefault returns a config with every default filled in except the node
+// identity and bootstrap peers, without generating any key. Init builds on
+// it.
+func NewDefault() *Config {
+	return &Config{
+		Version: CurrentConfigVersion,
 
-		Gateway: Gateway{
-			RootRedirect: "",
-			Writable:     false,
-			NoFetch:      false,
-			PathPrefixes: []string{},
-			HTTPHeaders: map[string][]string{
-				"Access-Control-Allow-Origin":  []string{"*"},
-				"Access-Control-Allow-Methods": []string{"GET"},
-				"Access-Control-Allow-Headers": []string{"X-Requested-With", "Range", "User-Agent"},
-			},
-			APICommands: []string{},
-		},
-		Services: DefaultServicesConfig(),
-		Reprovider: Reprovider{
-			Interval: "12h",
-			Strategy: "all",
+		API: API{
+			HTTPHeaders:  map[string][]string{},
+			ReadTimeout:  DefaultHTTPReadTimeout.String(),
+			WriteTimeout: DefaultHTTPWriteTimeout.String(),
+			IdleTimeout:  DefaultHTTPIdleTimeout.String(),
 		},
-		Swarm: SwarmConfig{
-			SwarmKey: DefaultSwarmKey,
-			ConnMgr: ConnMgr{
-				LowWater:    DefaultConnMgrLowWater,
-				HighWater:   DefaultConnMgrHighWater,
-				GracePeriod: DefaultConnMgrGracePeriod.String(),
-				Type:        "basic",
-			},
-			EnableAutoRelay: DefaultEnableAutoRelay,
+
+		// setup the node's default addresses.
+		// NOTE: tcp and quic swarm listen addrs for ip4 and ip6.
+		Addresses: swarmAddressesConfig(true),
+
+		Datastore: DefaultDatastoreConfig(),
+		Bootstrap: []string{},
+		Discovery: DefaultDiscovery(),
+		Routing:   DefaultRouting(),
+		Mounts:    DefaultMounts(),
+		Ipns:      DefaultIpns(),
+		Gateway:   DefaultGateway(),
+
+		Services:   DefaultServicesConfig(),
+		Import:     DefaultImportConfig(),
+		Reprovider: DefaultReprovider(),
+		Swarm:      DefaultSwarmConfig(),
+		Bitswap:    DefaultBitswap(),
+
+		Pubsub: PubsubConfig{
+			Router: PubsubRouterGossipsub,
 		},
 		Experimental: Experiments{
 			Libp2pStreamMounting: true, // Enabled for remote api
 			StorageClientEnabled: true,
-			RemoveOnUnpin:        rmOnUnpin,
 			HostsSyncEnabled:     DefaultHostsSyncEnabled,
 			HostsSyncMode:        DefaultHostsSyncMode.String(),
 		},
 	}
+}
+
+// WithOffline makes Init skip the default bootstrap peers, e.g. to provision
+// air-gapped nodes. The resulting config has no bootstrap peers, whatever
+// WithBootstrap says; add them later with AddBootstrapPeers or
+// SetBootstrapToDefault.
+func WithOffline() InitOption {
+	return func(o *initOptions) {
+		o.offline = true
+	}
+}
+
+// WithCidV1 makes the node add content as CIDv1 with raw leaves, hashed with
+// hashFunction, e.g. "blake2b-256", so that nodes provisioned together
+// produce identical CIDs. Init fails if hashFunction isn't a multihash
+// function known to this build; blake3 isn't, the go-multihash version this
+// package depends on predates it.
+func WithCidV1(hashFunction string) InitOption {
+	return func(o *initOptions) {
+		o.cidV1Hash = hashFunction
+	}
+}
+
+// DefaultDiscovery returns the default peer discovery settings.
+func DefaultDiscovery() Discovery {
+	return Discovery{
+		MDNS: MDNS{
+			Enabled:  true,
+			Interval: 10,
+		},
+	}
+}
+
+// DefaultRouting returns the default routing settings.
+func DefaultRouting() Routing {
+	return Routing{
+		Type: RoutingTypeDHT,
+	}
+}
+
+// DefaultMounts returns the default FUSE mount points.
+func DefaultMounts() Mounts {
+	return Mounts{
+		IPFS: "/btfs",
+		IPNS: "/btns",
+	}
+}
+
+// DefaultIpns returns the default IPNS settings.
+func DefaultIpns() Ipns {
+	return Ipns{
+		RecordLifetime:   DefaultIpnsRecordLifetime,
+		RepublishPeriod:  DefaultIpnsRepublishPeriod,
+		ResolveCacheSize: 128,
+	}
+}
+
+// DefaultGateway returns the default HTTP gateway settings.
+func DefaultGateway() Gateway {
+	return Gateway{
+		RootRedirect: "",
+		Writable:     false,
+		NoFetch:      false,
+		PathPrefixes: []string{},
+		HTTPHeaders: map[string][]string{
+			"Access-Control-Allow-Origin":  []string{"*"},
+			"Access-Control-Allow-Methods": []string{"GET"},
+			"Access-Control-Allow-Headers": []string{"X-Requested-With", "Range", "User-Agent"},
+		},
+		APICommands:  []string{},
+		ReadTimeout:  DefaultHTTPReadTimeout.String(),
+		WriteTimeout: DefaultHTTPWriteTimeout.String(),
+		IdleTimeout:  DefaultHTTPIdleTimeout.String(),
+	}
+}
+
+// DefaultReprovider returns the default reprovider settings.
+func DefaultReprovider() Reprovider {
+	return Reprovider{
+		Interval: "12h",
+		Strategy: ReproviderStrategyAll,
+	}
+}
+
+// DefaultBitswap returns the default bitswap settings, with both the server
+// and the client enabled.
+func DefaultBitswap() Bitswap {
+	return Bitswap{
+		ServerEnabled: True,
+		ClientEnabled: True,
+	}
+}
+
+// DefaultSwarmConfig returns the default swarm settings of a mainnet node.
+func DefaultSwarmConfig() SwarmConfig {
+	return SwarmConfig{
+		SwarmKey: DefaultSwarmKey,
+		ConnMgr: ConnMgr{
+			LowWater:    DefaultConnMgrLowWater,
+			HighWater:   DefaultConnMgrHighWater,
+			GracePeriod: DefaultConnMgrGracePeriod.String(),
+			Type:        ConnMgrTypeBasic,
+		},
+		EnableAutoRelay: DefaultEnableAutoRelay,
+	}
+}
+
+func Init(out io.Writer, nBitsForKeypair int, keyType string, importKey string, mnemonic string, rmOnUnpin bool,
+	opts ...InitOption) (*Config, error) {
+	var o initOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.cidV1Hash != "" {
+		if _, ok := mh.Names[strings.ToLower(o.cidV1Hash)]; !ok {
+			return nil, fmt.Errorf("cannot use %q for CIDv1: unknown multihash function", o.cidV1Hash)
+		}
+	}
+
+	var identity Identity
+	var err error
+	switch {
+	case o.keyFile != "":
+		identity, err = IdentityFromKeyFile(o.keyFile)
+		if err == nil {
+			fmt.Fprintf(out, "peer identity: %s\n", identity.PeerID)
+		}
+	case o.importMnemonic != "":
+		identity, err = IdentityFromMnemonic(out, o.importMnemonic, o.mnemonicPassphrase)
+	default:
+		identity, err = identityConfig(out, nBitsForKeypair, keyType, importKey, mnemonic, o.generator())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if o.passphrase != "" {
+		if err := EncryptIdentity(&identity, o.passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	var bootstrap []string
+	if o.offline {
+		bootstrap = []string{}
+	} else if o.customBootstrap {
+		if _, err := ParseBootstrapPeers(o.bootstrap); err != nil {
+			return nil, err
+		}
+		bootstrap = o.bootstrap
+	} else {
+		bootstrapPeers, err := DefaultBootstrapPeers()
+		if err != nil {
+			return nil, err
+		}
+		bootstrap = BootstrapPeerStrings(bootstrapPeers)
+	}
+
+	conf := NewDefault()
+	conf.Identity = identity
+	conf.Bootstrap = bootstrap
+	conf.Experimental.RemoveOnUnpin = rmOnUnpin
+	if o.disableQUIC {
+		conf.Addresses = swarmAddressesConfig(false)
+	}
+	if o.cidV1Hash != "" {
+		cidVersion := 1
+		conf.Import.CidVersion = &cidVersion
+		conf.Import.HashFunction = strings.ToLower(o.cidV1Hash)
+		conf.Import.UnixFSRawLeaves = True
+	}
+
+	if err := conf.applyProfiles(o.profiles, true); err != nil {
+		return nil, err
+	}
+	if err := conf.SetConnMgrType(conf.Swarm.ConnMgr.Type); err != nil {
+		return nil, err
+	}
 
 	return conf, nil
 }
@@ -116,6 +344,14 @@ const DefaultConnMgrHighWater = 900
 // water' mark
 const DefaultConnMgrLowWater = 600
 
+// ServerConnMgrHighWater is the minimum connection manager 'high water' mark
+// set by the server profile
+const ServerConnMgrHighWater = 1500
+
+// ServerConnMgrLowWater is the minimum connection manager 'low water' mark
+// set by the server profile
+const ServerConnMgrLowWater = 1000
+
 // DefaultConnMgrGracePeriod is the default value for the connection managers
 // grace period
 const DefaultConnMgrGracePeriod = time.Second * 20
@@ -136,72 +372,89 @@ const DefaultSwarmPort = 4001
 const DefaultEnableAutoRelay = true
 
 func addressesConfig() Addresses {
-	return Addresses{
-		Swarm: []string{
-			fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", DefaultSwarmPort),
-			fmt.Sprintf("/ip6/::/tcp/%d", DefaultSwarmPort),
+	return swarmAddressesConfig(true)
+}
+
+// swarmAddressesConfig returns the default addresses, listening on QUIC in
+// addition to TCP when enableQUIC is set.
+//
+// NOTE: QUIC is listed as /quic (draft-29) rather than /quic-v1, the multiaddr
+// version this package depends on does not know about the latter.
+func swarmAddressesConfig(enableQUIC bool) Addresses {
+	swarm := []string{
+		fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", DefaultSwarmPort),
+		fmt.Sprintf("/ip6/::/tcp/%d", DefaultSwarmPort),
+	}
+	if enableQUIC {
+		swarm = append(swarm,
 			fmt.Sprintf("/ip4/0.0.0.0/udp/%d/quic", DefaultSwarmPort),
 			fmt.Sprintf("/ip6/::/udp/%d/quic", DefaultSwarmPort),
-		},
-		Announce:   []string{},
-		NoAnnounce: []string{},
-		API:        Strings{"/ip4/127.0.0.1/tcp/5001"},
-		Gateway:    Strings{"/ip4/127.0.0.1/tcp/8080"},
-		RemoteAPI:  Strings{"/ip4/127.0.0.1/tcp/5101"},
+		)
+	}
+	return Addresses{
+		Swarm:          swarm,
+		Announce:       []string{},
+		AppendAnnounce: []string{},
+		NoAnnounce:     []string{},
+		API:            Strings{"/ip4/127.0.0.1/tcp/5001"},
+		Gateway:        Strings{"/ip4/127.0.0.1/tcp/8080"},
+		RemoteAPI:      Strings{"/ip4/127.0.0.1/tcp/5101"},
 	}
 }
 
 // DefaultDatastoreConfig is an internal function exported to aid in testing.
 func DefaultDatastoreConfig() Datastore {
+	ds, err := NewDatastoreConfig(DefaultDatastoreOptions())
+	if err != nil {
+		// programmer error.
+		panic(err)
+	}
+	return ds
+}
+
+// NewDatastoreConfig returns the default flatfs and leveldb datastore config,
+// tuned with opts.
+func NewDatastoreConfig(opts DatastoreOptions) (Datastore, error) {
+	if err := opts.Validate(); err != nil {
+		return Datastore{}, err
+	}
 	return Datastore{
 		StorageMax:         "10GB",
 		StorageGCWatermark: 90, // 90%
 		GCPeriod:           "1h",
 		BloomFilterSize:    0,
-		Spec:               flatfsSpec(),
-	}
+		Spec:               flatfsSpecWith(opts),
+	}, nil
+}
+
+// DefaultBadgerDatastoreConfig returns the default datastore config using a
+// badger datastore mounted at the root instead of flatfs and leveldb.
+func DefaultBadgerDatastoreConfig() Datastore {
+	ds := DefaultDatastoreConfig()
+	ds.Spec = badgerSpec()
+	return ds
 }
 
+// badgerSpec is a single measured badger datastore. It is not wrapped in a
+// mount so it implicitly serves the "/" mountpoint; keep it that way since
+// existing repos compare their stored spec against it.
 func badgerSpec() map[string]interface{} {
 	return map[string]interface{}{
 		"type":   "measure",
 		"prefix": "badger.datastore",
-		"child": map[string]interface{}{
-			"type":       "badgerds",
-			"path":       "badgerds",
-			"syncWrites": false,
-			"truncate":   true,
-		},
+		"child":  Badger("badgerds", false, true).build(),
 	}
 }
 
 func flatfsSpec() map[string]interface{} {
-	return map[string]interface{}{
-		"type": "mount",
-		"mounts": []interface{}{
-			map[string]interface{}{
-				"mountpoint": "/blocks",
-				"type":       "measure",
-				"prefix":     "flatfs.datastore",
-				"child": map[string]interface{}{
-					"type":      "flatfs",
-					"path":      "blocks",
-					"sync":      true,
-					"shardFunc": "/repo/flatfs/shard/v1/next-to-last/2",
-				},
-			},
-			map[string]interface{}{
-				"mountpoint": "/",
-				"type":       "measure",
-				"prefix":     "leveldb.datastore",
-				"child": map[string]interface{}{
-					"type":        "levelds",
-					"path":        "datastore",
-					"compression": "none",
-				},
-			},
-		},
-	}
+	return flatfsSpecWith(DefaultDatastoreOptions())
+}
+
+func flatfsSpecWith(opts DatastoreOptions) map[string]interface{} {
+	return NewDatastoreSpec().
+		Mount("/blocks", "flatfs.datastore", FlatFS("blocks", "/repo/flatfs/shard/v1/next-to-last/2", opts.FlatFSSync)).
+		Mount("/", "leveldb.datastore", LevelDB("datastore", opts.LevelDBCompression)).
+		Build()
 }
 
 // DefaultServicesConfig returns the default set of configs for external services.
@@ -253,56 +506,93 @@ func DefaultServicesConfigTestnet() Services {
 }
 
 // IdentityConfig initializes a new identity.
+//
+// nbits is only used for RSA keys, which must be at least ci.MinRsaKeyBits
+// long. Ed25519, Secp256k1 and ECDSA keys have a fixed size.
 func IdentityConfig(out io.Writer, nbits int, keyType string, importKey string, mnemonic string) (Identity, error) {
-	// TODO guard higher up
-	ident := Identity{}
+	return identityConfig(out, nbits, keyType, importKey, mnemonic, NewLibp2pKeyGenerator(nil))
+}
 
-	if nbits < ci.MinRsaKeyBits {
-		return ident, ci.ErrRsaKeyTooSmall
-	}
+// identityConfig is IdentityConfig generating the keypair with gen.
+func identityConfig(out io.Writer, nbits int, keyType string, importKey string, mnemonic string, gen KeyGenerator) (Identity, error) {
+	ident := Identity{}
 
 	var sk ci.PrivKey
-	var pk ci.PubKey
 	var err error
 	if importKey == "" {
-		var key int
-
 		switch keyType {
-		case "RSA":
-			key = ci.RSA
-		case "Ed25519":
-			key = ci.Ed25519
-		case "Secp256k1":
-			key = ci.Secp256k1
-		case "ECDSA":
-			key = ci.ECDSA
+		case "RSA", "Ed25519", "Secp256k1", "ECDSA":
 		default:
-			key = ci.Secp256k1
 			keyType = "Secp256k1"
 		}
 
-		fmt.Fprintf(out, "generating %v-bit %s keypair...", nbits, keyType)
-		sk, pk, err = ci.GenerateKeyPair(key, nbits)
-	} else {
-		fmt.Fprintf(out, "generating btfs node keypair with TRON key...")
-		skBytes, err := hex.DecodeString(importKey)
+		if keyType == "RSA" {
+			if nbits < ci.MinRsaKeyBits {
+				return ident, ci.ErrRsaKeyTooSmall
+			}
+			fmt.Fprintf(out, "generating %v-bit %s keypair...", nbits, keyType)
+		} else {
+			fmt.Fprintf(out, "generating %s keypair...", keyType)
+		}
+		var pk ci.PubKey
+		sk, pk, err = gen.Generate(keyType, nbits)
 		if err != nil {
-			return ident, errors.New("cannot decode importKey from a string to byte array")
+			return ident, err
+		}
+		if sk == nil || pk == nil || !sk.GetPublic().Equals(pk) {
+			return ident, errors.New("key generator returned a mismatched keypair")
 		}
-		sk, err = ci.UnmarshalSecp256k1PrivateKey(skBytes)
+	} else {
+		fmt.Fprintf(out, "generating btfs node keypair with TRON key...")
+		sk, err = importSecp256k1Key(importKey)
 		if err != nil {
 			return ident, err
 		}
-		pk = sk.GetPublic()
 	}
+	fmt.Fprintf(out, "done\n")
+
+	return identityFromPrivKey(out, sk, mnemonic)
+}
 
+// importSecp256k1Key decodes a hex encoded TRON private key.
+func importSecp256k1Key(importKey string) (ci.PrivKey, error) {
+	skBytes, err := hex.DecodeString(importKey)
 	if err != nil {
-		return ident, err
+		return nil, errors.New("cannot decode importKey from a string to byte array")
 	}
-	fmt.Fprintf(out, "done\n")
+	// the secp256k1 unmarshaller accepts any 32 bytes, make sure the
+	// scalar is an actual private key: 0 < k < N.
+	k := new(big.Int).SetBytes(skBytes)
+	if k.Sign() == 0 || k.Cmp(btcec.S256().N) >= 0 {
+		return nil, errors.New("importKey is not a valid secp256k1 private key")
+	}
+	sk, err := ci.UnmarshalSecp256k1PrivateKey(skBytes)
+	if err != nil {
+		return nil, fmt.Errorf("importKey is not a valid secp256k1 private key: %s", err)
+	}
+	return sk, nil
+}
+
+// generateSecp256k1Key draws scalars from src until one is a valid private
+// key.
+func generateSecp256k1Key(src io.Reader) (ci.PrivKey, error) {
+	buf := make([]byte, 32)
+	for {
+		if _, err := io.ReadFull(src, buf); err != nil {
+			return nil, err
+		}
+		k := new(big.Int).SetBytes(buf)
+		if k.Sign() != 0 && k.Cmp(btcec.S256().N) < 0 {
+			return ci.UnmarshalSecp256k1PrivateKey(buf)
+		}
+	}
+}
+
+// identityFromPrivKey builds the identity for the given private key.
+func identityFromPrivKey(out io.Writer, sk ci.PrivKey, mnemonic string) (Identity, error) {
+	ident := Identity{}
 
-	// currently storing key unencrypted. in the future we need to encrypt it.
-	// TODO(security)
+	// stored unencrypted here, Init encrypts it when given a passphrase.
 	skbytes, err := sk.Bytes()
 	if err != nil {
 		return ident, err
@@ -310,7 +600,7 @@ func IdentityConfig(out io.Writer, nbits int, keyType string, importKey string,
 	ident.PrivKey = base64.StdEncoding.EncodeToString(skbytes)
 	ident.Mnemonic = mnemonic
 
-	id, err := peer.IDFromPublicKey(pk)
+	id, err := peer.IDFromPublicKey(sk.GetPublic())
 	if err != nil {
 		return ident, err
 	}
@@ -0,0 +1,282 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	mathrand "math/rand"
+	"reflect"
+	"testing"
+
+	ci "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func hasAddr(addrs []string, addr string) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func TestInitQUIC(t *testing.T) {
+	quicAddrs := []string{"/ip4/0.0.0.0/udp/4001/quic", "/ip6/::/udp/4001/quic"}
+
+	cfg, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range quicAddrs {
+		if !hasAddr(cfg.Addresses.Swarm, addr) {
+			t.Fatalf("expected %s in default swarm addresses %v", addr, cfg.Addresses.Swarm)
+		}
+	}
+
+	cfg, err = Init(ioutil.Discard, 2048, "Ed25519", "", "", false, WithQUIC(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range quicAddrs {
+		if hasAddr(cfg.Addresses.Swarm, addr) {
+			t.Fatalf("expected %s to be omitted from swarm addresses %v", addr, cfg.Addresses.Swarm)
+		}
+	}
+	if err := cfg.Addresses.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInitProfiles(t *testing.T) {
+	cfg, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false, WithProfiles("test", "badgerds"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Bootstrap) != 0 || cfg.Discovery.MDNS.Enabled {
+		t.Fatal("expected test profile to be applied")
+	}
+	if cfg.Datastore.Spec["prefix"] != "badger.datastore" {
+		t.Fatal("expected badgerds profile to be applied")
+	}
+
+	if err := cfg.ApplyProfiles([]string{"flatfs"}); err == nil {
+		t.Fatal("expected init only profile to be rejected")
+	}
+	if err := cfg.ApplyProfiles([]string{"lowpower", "nope"}); err == nil {
+		t.Fatal("expected unknown profile to be rejected")
+	}
+	if cfg.Swarm.ConnMgr.HighWater != 40 {
+		t.Fatal("expected lowpower profile to be applied")
+	}
+}
+
+func TestInitBootstrap(t *testing.T) {
+	custom := DefaultTestnetBootstrapAddresses[:2]
+	cfg, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false, WithBootstrap(custom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Bootstrap) != len(custom) {
+		t.Fatalf("expected bootstrap %v, got %v", custom, cfg.Bootstrap)
+	}
+	for i := range custom {
+		if cfg.Bootstrap[i] != custom[i] {
+			t.Fatalf("expected bootstrap %v, got %v", custom, cfg.Bootstrap)
+		}
+	}
+
+	cfg, err = Init(ioutil.Discard, 2048, "Ed25519", "", "", false, WithBootstrap([]string{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Bootstrap == nil || len(cfg.Bootstrap) != 0 {
+		t.Fatalf("expected empty bootstrap, got %v", cfg.Bootstrap)
+	}
+
+	if _, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false, WithBootstrap([]string{"/ip4/1.2.3.4/tcp/4001"})); err == nil {
+		t.Fatal("expected bootstrap address without peer id to be rejected")
+	}
+
+	cfg, err = Init(ioutil.Discard, 2048, "Ed25519", "", "", false, WithOffline())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Bootstrap == nil || len(cfg.Bootstrap) != 0 {
+		t.Fatalf("expected no bootstrap peers offline, got %v", cfg.Bootstrap)
+	}
+}
+
+func TestInitIpns(t *testing.T) {
+	cfg, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Ipns.RecordLifetime != "24h" || cfg.Ipns.RepublishPeriod != "4h" {
+		t.Fatalf("unexpected ipns defaults %+v", cfg.Ipns)
+	}
+	if err := cfg.Ipns.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDefaultSections(t *testing.T) {
+	if d := DefaultDiscovery(); !d.MDNS.Enabled || d.MDNS.Interval != 10 {
+		t.Fatalf("unexpected discovery defaults %+v", d)
+	}
+	if r := DefaultRouting(); r.Type != "dht" {
+		t.Fatalf("unexpected routing defaults %+v", r)
+	}
+	if m := DefaultMounts(); m.IPFS != "/btfs" || m.IPNS != "/btns" {
+		t.Fatalf("unexpected mount defaults %+v", m)
+	}
+	if i := DefaultIpns(); i.RecordLifetime != "24h" || i.RepublishPeriod != "4h" || i.ResolveCacheSize != 128 {
+		t.Fatalf("unexpected ipns defaults %+v", i)
+	}
+	if r := DefaultReprovider(); r.Interval != "12h" || r.Strategy != "all" {
+		t.Fatalf("unexpected reprovider defaults %+v", r)
+	}
+	g := DefaultGateway()
+	if g.Writable || g.NoFetch || len(g.PathPrefixes) != 0 || g.HTTPHeaders["Access-Control-Allow-Origin"][0] != "*" {
+		t.Fatalf("unexpected gateway defaults %+v", g)
+	}
+	s := DefaultSwarmConfig()
+	if s.SwarmKey != DefaultSwarmKey || !s.EnableAutoRelay ||
+		s.ConnMgr != (ConnMgr{Type: "basic", LowWater: 600, HighWater: 900, GracePeriod: "20s"}) {
+		t.Fatalf("unexpected swarm defaults %+v", s)
+	}
+
+	c := NewDefault()
+	if !reflect.DeepEqual(c.Gateway, g) || !reflect.DeepEqual(c.Swarm, s) || c.Mounts != DefaultMounts() {
+		t.Fatal("expected NewDefault to be composed of the section defaults")
+	}
+	g.HTTPHeaders["X-Test"] = []string{"1"}
+	if _, ok := DefaultGateway().HTTPHeaders["X-Test"]; ok {
+		t.Fatal("expected every call to return a new gateway config")
+	}
+}
+
+func TestNewDefault(t *testing.T) {
+	c := NewDefault()
+	if c.Identity != (Identity{}) {
+		t.Fatalf("expected a zero identity, got %+v", c.Identity)
+	}
+	if len(c.Bootstrap) != 0 {
+		t.Fatalf("expected no bootstrap peers, got %v", c.Bootstrap)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Experimental.RemoveOnUnpin || len(cfg.Bootstrap) == 0 || cfg.Identity.PeerID == "" {
+		t.Fatal("expected Init to fill in the identity, bootstrap peers and init flags")
+	}
+	cfg.Identity, cfg.Bootstrap, cfg.Experimental.RemoveOnUnpin = c.Identity, c.Bootstrap, false
+	changes, err := Diff(c, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected Init to match NewDefault otherwise, got %v", changes)
+	}
+}
+
+func TestInitWithRand(t *testing.T) {
+	for _, keyType := range []string{"Secp256k1", "Ed25519"} {
+		seed := func() InitOption {
+			return WithRand(mathrand.New(mathrand.NewSource(42)))
+		}
+		a, err := Init(ioutil.Discard, 2048, keyType, "", "", false, seed())
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Init(ioutil.Discard, 2048, keyType, "", "", false, seed())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Identity.PeerID != b.Identity.PeerID || a.Identity.PrivKey != b.Identity.PrivKey {
+			t.Fatalf("%s: expected the same seed to produce the same identity", keyType)
+		}
+
+		c, err := Init(ioutil.Discard, 2048, keyType, "", "", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Identity.PeerID == a.Identity.PeerID {
+			t.Fatalf("%s: expected crypto/rand to produce a different identity", keyType)
+		}
+	}
+}
+
+func TestInitCidV1(t *testing.T) {
+	c, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false, WithCidV1("blake2b-256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp := c.Import
+	if imp.CidVersion == nil || *imp.CidVersion != 1 || imp.HashFunction != "blake2b-256" || imp.UnixFSRawLeaves != True {
+		t.Fatalf("expected CIDv1 import settings, got %+v", imp)
+	}
+	if err := imp.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hash := range []string{"not-a-hash", "blake3"} {
+		if _, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false, WithCidV1(hash)); err == nil {
+			t.Fatalf("expected unknown hash function %q to be rejected", hash)
+		}
+	}
+}
+
+type fixedKeyGenerator struct {
+	sk       ci.PrivKey
+	keyTypes []string
+}
+
+func (g *fixedKeyGenerator) Generate(keyType string, nbits int) (ci.PrivKey, ci.PubKey, error) {
+	g.keyTypes = append(g.keyTypes, keyType)
+	if g.sk == nil {
+		return nil, nil, errors.New("no key")
+	}
+	return g.sk, g.sk.GetPublic(), nil
+}
+
+func TestInitKeyGenerator(t *testing.T) {
+	sk, _, err := ci.GenerateEd25519Key(bytes.NewReader(make([]byte, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := peer.IDFromPrivateKey(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gen := &fixedKeyGenerator{sk: sk}
+	cfg, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false, WithKeyGenerator(gen))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Identity.PeerID != expected.Pretty() {
+		t.Fatalf("expected peer ID %s, got %s", expected.Pretty(), cfg.Identity.PeerID)
+	}
+	if !reflect.DeepEqual(gen.keyTypes, []string{"Ed25519"}) {
+		t.Fatalf("unexpected generator calls %v", gen.keyTypes)
+	}
+	if _, err := cfg.RotateIdentity(ioutil.Discard, 2048, "nope", WithKeyGenerator(gen)); err != nil {
+		t.Fatal(err)
+	}
+	if gen.keyTypes[1] != "Secp256k1" {
+		t.Fatalf("expected unknown key types to fall back to Secp256k1, got %s", gen.keyTypes[1])
+	}
+
+	if _, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false, WithKeyGenerator(&fixedKeyGenerator{})); err == nil {
+		t.Fatal("expected generator error to be returned")
+	}
+	if _, _, err := NewLibp2pKeyGenerator(nil).Generate("DSA", 0); err == nil {
+		t.Fatal("expected unknown key type to be rejected")
+	}
+}
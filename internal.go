@@ -0,0 +1,47 @@
+package config
+
+// Internal holds low level tuning knobs that most users should leave alone.
+// Everything is optional and unset by default.
+type Internal struct {
+	Bitswap *InternalBitswap `json:",omitempty"`
+
+	// UnixFSShardingSizeThreshold is the serialized size above which UnixFS
+	// directories are sharded, e.g. "256KiB".
+	UnixFSShardingSizeThreshold string `json:",omitempty"`
+}
+
+// InternalBitswap tunes the bitswap engine. Zero values keep the bitswap
+// defaults.
+type InternalBitswap struct {
+	TaskWorkerCount             int `json:",omitempty"`
+	EngineBlockstoreWorkerCount int `json:",omitempty"`
+	EngineTaskWorkerCount       int `json:",omitempty"`
+	MaxOutstandingBytesPerPeer  int `json:",omitempty"`
+}
+
+// Validate checks that the worker counts are not negative and that the
+// sharding threshold is a byte quantity.
+func (i Internal) Validate() error {
+	var errs ValidationErrors
+	if b := i.Bitswap; b != nil {
+		for _, f := range []struct {
+			name  string
+			value int
+		}{
+			{"TaskWorkerCount", b.TaskWorkerCount},
+			{"EngineBlockstoreWorkerCount", b.EngineBlockstoreWorkerCount},
+			{"EngineTaskWorkerCount", b.EngineTaskWorkerCount},
+			{"MaxOutstandingBytesPerPeer", b.MaxOutstandingBytesPerPeer},
+		} {
+			if f.value < 0 {
+				errs.add(fieldErrorf("Internal.Bitswap."+f.name, "must not be negative, got %d", f.value))
+			}
+		}
+	}
+	if i.UnixFSShardingSizeThreshold != "" {
+		if _, err := parseByteSize(i.UnixFSShardingSizeThreshold); err != nil {
+			errs.add(fieldErrorf("Internal.UnixFSShardingSizeThreshold", "%s", err))
+		}
+	}
+	return errs.errOrNil()
+}
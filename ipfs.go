@@ -0,0 +1,128 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ipfsPathRenames maps the BTFS namespace roots to their go-ipfs
+// counterparts.
+var ipfsPathRenames = [][2]string{
+	{"/btfs", "/ipfs"},
+	{"/btns", "/ipns"},
+}
+
+// btfsOnlyKeys lists, per top level section, the keys go-ipfs doesn't know.
+// A nil list stands for the whole section.
+var btfsOnlyKeys = map[string][]string{
+	"Version":        nil,
+	"Identities":     nil,
+	"ActiveIdentity": nil,
+	"Services":       nil,
+	"UI":             nil,
+	"Logging":        nil,
+	"Identity":       {"Mnemonic", "EncryptedMnemonic", "EncryptedPrivKey"},
+	"Datastore":      {"BasePath"},
+	"Addresses":      {"RemoteAPI"},
+	"API":            {"ReadTimeout", "WriteTimeout", "IdleTimeout"},
+	"Gateway":        {"ReadTimeout", "WriteTimeout", "IdleTimeout"},
+	"Swarm":          {"SwarmKey"},
+	"Experimental": {
+		"StorageHostEnabled", "StorageClientEnabled", "Analytics", "RemoveOnUnpin",
+		"HostsSyncEnabled", "HostsSyncMode", "DisableAutoUpdate", "HostRepairEnabled",
+		"HostChallengeEnabled",
+	},
+}
+
+// ToIPFSCompatible converts the config to the go-ipfs config file format.
+// The two are mostly identical, the differences being:
+//
+//   - Mounts.IPFS and Mounts.IPNS, and the paths in Gateway.PathPrefixes and
+//     Gateway.PublicGateways.*.Paths, use /ipfs and /ipns instead of /btfs
+//     and /btns.
+//   - BTFS only settings are dropped: Version, Identities, ActiveIdentity,
+//     Services, UI, Logging, Identity.Mnemonic, Identity.EncryptedMnemonic,
+//     Identity.EncryptedPrivKey, Datastore.BasePath, Addresses.RemoteAPI,
+//     the API and Gateway server timeouts, Swarm.SwarmKey (go-ipfs reads it
+//     from the swarm.key file) and the storage related Experimental flags.
+//
+// go-ipfs can't open encrypted or referenced private keys, so those are
+// rejected.
+func (c *Config) ToIPFSCompatible() (map[string]interface{}, error) {
+	if c.Identity.IsEncrypted() || c.Identity.IsKeyReference() {
+		return nil, fmt.Errorf("cannot convert to go-ipfs config: Identity.PrivKey must be a plaintext key")
+	}
+	m, err := c.ToMap()
+	if err != nil {
+		return nil, err
+	}
+	for section, keys := range btfsOnlyKeys {
+		if keys == nil {
+			delete(m, section)
+			continue
+		}
+		if sm, ok := m[section].(map[string]interface{}); ok {
+			for _, k := range keys {
+				delete(sm, k)
+			}
+		}
+	}
+	renamePaths(m, 0, 1)
+	return m, nil
+}
+
+// FromIPFSConfig reads a go-ipfs config file, translating the differences
+// listed on ToIPFSCompatible. Keys unknown to BTFS, such as Migration, are
+// preserved in Extra. BTFS only sections are left empty; run MigrateConfig
+// and EnsureDefaults to fill them in.
+func FromIPFSConfig(raw []byte) (*Config, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("failure to decode go-ipfs config: %s", err)
+	}
+	renamePaths(m, 1, 0)
+	return FromMap(m)
+}
+
+// renamePaths rewrites the namespace roots of the path valued fields of m
+// from ipfsPathRenames[i][from] to ipfsPathRenames[i][to].
+func renamePaths(m map[string]interface{}, from, to int) {
+	rename := func(v interface{}) interface{} {
+		p, ok := v.(string)
+		if !ok {
+			return v
+		}
+		for _, r := range ipfsPathRenames {
+			if p == r[from] || strings.HasPrefix(p, r[from]+"/") {
+				return r[to] + strings.TrimPrefix(p, r[from])
+			}
+		}
+		return p
+	}
+	renameAll := func(v interface{}) {
+		if list, ok := v.([]interface{}); ok {
+			for i := range list {
+				list[i] = rename(list[i])
+			}
+		}
+	}
+
+	if mounts, ok := m["Mounts"].(map[string]interface{}); ok {
+		for _, k := range []string{"IPFS", "IPNS"} {
+			if v, ok := mounts[k]; ok {
+				mounts[k] = rename(v)
+			}
+		}
+	}
+	if gw, ok := m["Gateway"].(map[string]interface{}); ok {
+		renameAll(gw["PathPrefixes"])
+		if public, ok := gw["PublicGateways"].(map[string]interface{}); ok {
+			for _, spec := range public {
+				if spec, ok := spec.(map[string]interface{}); ok {
+					renameAll(spec["Paths"])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestIPFSCompatible(t *testing.T) {
+	c, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Gateway.PathPrefixes = []string{"/btfs/QmSite", "/blog"}
+	c.Gateway.PublicGateways = map[string]*GatewaySpec{
+		"gateway.example.com": {Paths: []string{"/btfs", "/btns"}},
+	}
+
+	m, err := c.ToIPFSCompatible()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mounts := m["Mounts"].(map[string]interface{})
+	if mounts["IPFS"] != "/ipfs" || mounts["IPNS"] != "/ipns" {
+		t.Fatalf("expected go-ipfs mount points, got %v", mounts)
+	}
+	gw := m["Gateway"].(map[string]interface{})
+	if !reflect.DeepEqual(gw["PathPrefixes"], []interface{}{"/ipfs/QmSite", "/blog"}) {
+		t.Fatalf("expected go-ipfs path prefixes, got %v", gw["PathPrefixes"])
+	}
+	for _, key := range []string{"Services", "UI", "Version"} {
+		if _, ok := m[key]; ok {
+			t.Fatalf("expected %s to be dropped", key)
+		}
+	}
+	if _, ok := m["Swarm"].(map[string]interface{})["SwarmKey"]; ok {
+		t.Fatal("expected Swarm.SwarmKey to be dropped")
+	}
+
+	m["Migration"] = map[string]interface{}{"Keep": "cache"}
+	raw, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := FromIPFSConfig(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.Mounts != c.Mounts {
+		t.Fatalf("expected mounts %+v, got %+v", c.Mounts, back.Mounts)
+	}
+	if !reflect.DeepEqual(back.Gateway.PathPrefixes, c.Gateway.PathPrefixes) ||
+		!reflect.DeepEqual(back.Gateway.PublicGateways["gateway.example.com"].Paths, []string{"/btfs", "/btns"}) {
+		t.Fatalf("expected gateway paths to round trip, got %+v", back.Gateway)
+	}
+	if back.Identity.PeerID != c.Identity.PeerID || back.Identity.PrivKey != c.Identity.PrivKey {
+		t.Fatal("expected the identity to round trip")
+	}
+	if _, ok := back.Extra["Migration"]; !ok {
+		t.Fatal("expected go-ipfs only keys to be preserved")
+	}
+
+	if err := EncryptIdentity(&c.Identity, "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ToIPFSCompatible(); err == nil {
+		t.Fatal("expected encrypted key to be rejected")
+	}
+}
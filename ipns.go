@@ -1,8 +1,54 @@
 package config
 
+import "time"
+
+// Defaults set by Init for IPNS records published by this node.
+const (
+	DefaultIpnsRecordLifetime  = "24h"
+	DefaultIpnsRepublishPeriod = "4h"
+)
+
 type Ipns struct {
 	RepublishPeriod string
 	RecordLifetime  string
 
 	ResolveCacheSize int
+
+	// MaxCacheTTL caps the time resolved names are cached, whatever the
+	// TTL of the record.
+	MaxCacheTTL *string `json:",omitempty"`
+
+	// UsePubsub enables publishing and resolving records over pubsub.
+	UsePubsub Flag `json:",omitempty"`
+}
+
+// Validate checks the IPNS settings. Records must be republished before they
+// expire, so RepublishPeriod has to be shorter than RecordLifetime.
+func (i Ipns) Validate() error {
+	var errs ValidationErrors
+	if i.ResolveCacheSize < 0 {
+		errs.add(fieldErrorf("Ipns.ResolveCacheSize", "must not be negative, got %d", i.ResolveCacheSize))
+	}
+
+	var republish, lifetime time.Duration
+	var err error
+	if i.RepublishPeriod != "" {
+		if republish, err = time.ParseDuration(i.RepublishPeriod); err != nil {
+			errs.add(fieldErrorf("Ipns.RepublishPeriod", "%s", err))
+		}
+	}
+	if i.RecordLifetime != "" {
+		if lifetime, err = time.ParseDuration(i.RecordLifetime); err != nil {
+			errs.add(fieldErrorf("Ipns.RecordLifetime", "%s", err))
+		}
+	}
+	if republish > 0 && lifetime > 0 && republish >= lifetime {
+		errs.add(fieldErrorf("Ipns.RepublishPeriod", "%s must be shorter than RecordLifetime (%s)", i.RepublishPeriod, i.RecordLifetime))
+	}
+	if i.MaxCacheTTL != nil {
+		if _, err := time.ParseDuration(*i.MaxCacheTTL); err != nil {
+			errs.add(fieldErrorf("Ipns.MaxCacheTTL", "%s", err))
+		}
+	}
+	return errs.errOrNil()
 }
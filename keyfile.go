@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	ci "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// IdentityFromKeyBytes builds an identity from a libp2p serialized private
+// key, as written by crypto.MarshalPrivateKey, either raw or base64 encoded.
+// The key type is read from the serialized key.
+func IdentityFromKeyBytes(data []byte) (Identity, error) {
+	sk, err := unmarshalKeyBytes(data)
+	if err != nil {
+		return Identity{}, err
+	}
+	return identityFromPrivKey(ioutil.Discard, sk, "")
+}
+
+// unmarshalKeyBytes decodes a raw or base64 encoded libp2p private key.
+func unmarshalKeyBytes(data []byte) (ci.PrivKey, error) {
+	sk, err := ci.UnmarshalPrivateKey(data)
+	if err != nil {
+		decoded, decodeErr := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+		if decodeErr != nil {
+			return nil, fmt.Errorf("invalid libp2p private key: %s", err)
+		}
+		if sk, err = ci.UnmarshalPrivateKey(decoded); err != nil {
+			return nil, fmt.Errorf("invalid libp2p private key: %s", err)
+		}
+	}
+	return sk, nil
+}
+
+// IdentityFromKeyFile reads a libp2p serialized private key from path, see
+// IdentityFromKeyBytes.
+func IdentityFromKeyFile(path string) (Identity, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Identity{}, fmt.Errorf("cannot read key file: %s", err)
+	}
+	ident, err := IdentityFromKeyBytes(data)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%s: %s", path, err)
+	}
+	return ident, nil
+}
+
+// Identity.PrivKey prefixes referring to key material kept outside the
+// config, see ResolvePrivateKey.
+const (
+	EnvPrivKeyPrefix  = "env:"
+	FilePrivKeyPrefix = "file:"
+)
+
+// IsKeyReference reports whether Identity.PrivKey refers to key material kept
+// outside the config instead of holding the key.
+func (i *Identity) IsKeyReference() bool {
+	return strings.HasPrefix(i.PrivKey, EnvPrivKeyPrefix) || strings.HasPrefix(i.PrivKey, FilePrivKeyPrefix)
+}
+
+// ResolvePrivateKey decodes the private key of ident, dereferencing
+// "env:NAME" and "file:/path/to/key" references through getenv and readFile
+// first; nil functions default to os.Getenv and ioutil.ReadFile. Referenced
+// keys are libp2p serialized, raw or base64 encoded, see IdentityFromKeyBytes.
+// Plain base64 keys decode as before, while encrypted keys need
+// DecryptIdentity. The key must match PeerID when it is set.
+func (ident Identity) ResolvePrivateKey(getenv func(string) string, readFile func(string) ([]byte, error)) (ci.PrivKey, error) {
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	if readFile == nil {
+		readFile = ioutil.ReadFile
+	}
+
+	var sk ci.PrivKey
+	var err error
+	switch {
+	case strings.HasPrefix(ident.PrivKey, EnvPrivKeyPrefix):
+		name := strings.TrimPrefix(ident.PrivKey, EnvPrivKeyPrefix)
+		value := getenv(name)
+		if value == "" {
+			return nil, fmt.Errorf("private key environment variable %s is not set", name)
+		}
+		if sk, err = unmarshalKeyBytes([]byte(value)); err != nil {
+			return nil, fmt.Errorf("private key from environment variable %s: %s", name, err)
+		}
+	case strings.HasPrefix(ident.PrivKey, FilePrivKeyPrefix):
+		path := strings.TrimPrefix(ident.PrivKey, FilePrivKeyPrefix)
+		data, err := readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read private key file: %s", err)
+		}
+		if sk, err = unmarshalKeyBytes(data); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	case ident.IsEncrypted():
+		return nil, ErrPassphraseRequired
+	case strings.Contains(ident.PrivKey, ":"):
+		// base64 never contains a colon.
+		scheme := ident.PrivKey[:strings.Index(ident.PrivKey, ":")]
+		return nil, fmt.Errorf("unknown private key reference scheme %q", scheme)
+	default:
+		if sk, err = DecryptIdentity(ident, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if ident.PeerID != "" {
+		id, err := peer.Decode(ident.PeerID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer id %q: %s", ident.PeerID, err)
+		}
+		if !id.MatchesPrivateKey(sk) {
+			return nil, fmt.Errorf("private key does not match peer id %s", ident.PeerID)
+		}
+	}
+	return sk, nil
+}
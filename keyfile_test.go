@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ci "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestIdentityFromKeyBytes(t *testing.T) {
+	for _, keyType := range []int{ci.Ed25519, ci.Secp256k1} {
+		sk, _, err := ci.GenerateKeyPair(keyType, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := ci.MarshalPrivateKey(sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := peer.IDFromPrivateKey(sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, data := range [][]byte{raw, []byte(base64.StdEncoding.EncodeToString(raw) + "\n")} {
+			ident, err := IdentityFromKeyBytes(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ident.PeerID != id.Pretty() {
+				t.Fatalf("expected peer id %s, got %s", id.Pretty(), ident.PeerID)
+			}
+			decoded, err := ident.DecodePrivateKey("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded.Type() != sk.Type() {
+				t.Fatalf("expected key type %s, got %s", sk.Type(), decoded.Type())
+			}
+		}
+	}
+
+	if _, err := IdentityFromKeyBytes([]byte("not a key")); err == nil {
+		t.Fatal("expected garbage to be rejected")
+	}
+}
+
+func TestInitWithKeyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "btfs-keyfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sk, _, err := ci.GenerateKeyPair(ci.Ed25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ci.MarshalPrivateKey(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "node.key")
+	if err := ioutil.WriteFile(path, raw, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Init(ioutil.Discard, 2048, "", "", "", false, WithKeyFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := peer.IDFromPrivateKey(sk)
+	if cfg.Identity.PeerID != id.Pretty() {
+		t.Fatalf("expected peer id %s, got %s", id.Pretty(), cfg.Identity.PeerID)
+	}
+
+	if _, err := Init(ioutil.Discard, 2048, "", "", "", false, WithKeyFile(filepath.Join(dir, "missing"))); err == nil {
+		t.Fatal("expected missing key file to fail")
+	}
+}
+
+func TestResolvePrivateKey(t *testing.T) {
+	sk, _, err := ci.GenerateKeyPair(ci.Ed25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ci.MarshalPrivateKey(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	getenv := func(name string) string {
+		if name == "BTFS_PRIV_KEY" {
+			return base64.StdEncoding.EncodeToString(raw)
+		}
+		return ""
+	}
+	readFile := func(path string) ([]byte, error) {
+		if path == "/keys/node.key" {
+			return raw, nil
+		}
+		return nil, os.ErrNotExist
+	}
+
+	for _, ref := range []string{"env:BTFS_PRIV_KEY", "file:/keys/node.key", base64.StdEncoding.EncodeToString(raw)} {
+		ident := Identity{PeerID: id.Pretty(), PrivKey: ref}
+		resolved, err := ident.ResolvePrivateKey(getenv, readFile)
+		if err != nil {
+			t.Fatalf("%s: %s", ref, err)
+		}
+		if !resolved.Equals(sk) {
+			t.Fatalf("%s: resolved the wrong key", ref)
+		}
+	}
+
+	for _, ref := range []string{"env:MISSING", "file:/keys/missing.key", "vault:secret/btfs"} {
+		ident := Identity{PeerID: id.Pretty(), PrivKey: ref}
+		if _, err := ident.ResolvePrivateKey(getenv, readFile); err == nil {
+			t.Fatalf("expected %s to fail", ref)
+		}
+	}
+
+	other, err := IdentityConfig(ioutil.Discard, 0, "Ed25519", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ident := Identity{PeerID: other.PeerID, PrivKey: "env:BTFS_PRIV_KEY"}
+	if _, err := ident.ResolvePrivateKey(getenv, readFile); err == nil {
+		t.Fatal("expected key not matching the peer id to be rejected")
+	}
+
+	c := &Config{Identity: Identity{PeerID: id.Pretty(), PrivKey: "env:BTFS_PRIV_KEY"}}
+	if c.HasPlaintextPrivateKey() || !c.HasIdentity() {
+		t.Fatal("expected a key reference to be a usable, non plaintext identity")
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	ci "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// KeyGenerator creates the node identity keypair during Init and
+// RotateIdentity, see WithKeyGenerator. keyType is one of "RSA", "Ed25519",
+// "Secp256k1" or "ECDSA" and nbits is only meaningful for RSA keys.
+type KeyGenerator interface {
+	Generate(keyType string, nbits int) (ci.PrivKey, ci.PubKey, error)
+}
+
+// WithKeyGenerator makes Init generate the identity keypair with g instead
+// of libp2p, e.g. to keep the key in a hardware security module or to get a
+// fixed key in tests. It takes precedence over WithRand.
+func WithKeyGenerator(g KeyGenerator) InitOption {
+	return func(o *initOptions) {
+		o.keyGenerator = g
+	}
+}
+
+// NewLibp2pKeyGenerator returns the default KeyGenerator, backed by libp2p
+// and reading its randomness from src, or from crypto/rand when src is nil.
+func NewLibp2pKeyGenerator(src io.Reader) KeyGenerator {
+	if src == nil {
+		src = rand.Reader
+	}
+	return libp2pKeyGenerator{src: src}
+}
+
+type libp2pKeyGenerator struct {
+	src io.Reader
+}
+
+func (g libp2pKeyGenerator) Generate(keyType string, nbits int) (ci.PrivKey, ci.PubKey, error) {
+	var key int
+	switch keyType {
+	case "RSA":
+		key = ci.RSA
+	case "Ed25519":
+		key = ci.Ed25519
+	case "Secp256k1":
+		// libp2p ignores the reader for secp256k1 keys.
+		sk, err := generateSecp256k1Key(g.src)
+		if err != nil {
+			return nil, nil, err
+		}
+		return sk, sk.GetPublic(), nil
+	case "ECDSA":
+		key = ci.ECDSA
+	default:
+		return nil, nil, fmt.Errorf("unknown key type %q", keyType)
+	}
+	if key == ci.RSA && nbits < ci.MinRsaKeyBits {
+		return nil, nil, ci.ErrRsaKeyTooSmall
+	}
+	return ci.GenerateKeyPairWithReader(key, nbits, g.src)
+}
+
+// generator returns the KeyGenerator selected by the options.
+func (o initOptions) generator() KeyGenerator {
+	if o.keyGenerator != nil {
+		return o.keyGenerator
+	}
+	return NewLibp2pKeyGenerator(o.rand)
+}
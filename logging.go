@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultLogLevel is used when Logging.Level is unset.
+const DefaultLogLevel = "error"
+
+// LogLevels lists the accepted log levels, from most to least verbose.
+var LogLevels = []string{"debug", "info", "warn", "error", "dpanic", "panic", "fatal"}
+
+// Logging configures log verbosity, persisting what would otherwise be set
+// through environment variables.
+type Logging struct {
+	// Level is the level of every subsystem not listed in Subsystems.
+	Level string `json:",omitempty"`
+
+	// Subsystems overrides the level of individual log subsystems.
+	Subsystems map[string]string `json:",omitempty"`
+}
+
+// Normalized returns the effective, lower cased level of every configured
+// subsystem. The "*" key holds the level applied to all other subsystems.
+func (l Logging) Normalized() (map[string]string, error) {
+	def := DefaultLogLevel
+	if l.Level != "" {
+		lvl, err := normalizeLogLevel(l.Level)
+		if err != nil {
+			return nil, fieldErrorf("Logging.Level", "%s", err)
+		}
+		def = lvl
+	}
+
+	levels := map[string]string{"*": def}
+	for name, level := range l.Subsystems {
+		if level == "" {
+			levels[name] = def
+			continue
+		}
+		lvl, err := normalizeLogLevel(level)
+		if err != nil {
+			return nil, fieldErrorf("Logging.Subsystems."+name, "%s", err)
+		}
+		levels[name] = lvl
+	}
+	return levels, nil
+}
+
+// Validate checks that every configured log level is known.
+func (l Logging) Validate() error {
+	var errs ValidationErrors
+	if l.Level != "" {
+		if _, err := normalizeLogLevel(l.Level); err != nil {
+			errs.add(fieldErrorf("Logging.Level", "%s", err))
+		}
+	}
+	names := make([]string, 0, len(l.Subsystems))
+	for name := range l.Subsystems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if level := l.Subsystems[name]; level != "" {
+			if _, err := normalizeLogLevel(level); err != nil {
+				errs.add(fieldErrorf("Logging.Subsystems."+name, "%s", err))
+			}
+		}
+	}
+	return errs.errOrNil()
+}
+
+func normalizeLogLevel(level string) (string, error) {
+	lvl := strings.ToLower(strings.TrimSpace(level))
+	for _, known := range LogLevels {
+		if lvl == known {
+			return lvl, nil
+		}
+	}
+	return "", fmt.Errorf("unknown log level %q, must be one of %s", level, strings.Join(LogLevels, ", "))
+}
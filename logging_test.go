@@ -0,0 +1,42 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoggingNormalized(t *testing.T) {
+	levels, err := (Logging{}).Normalized()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(levels, map[string]string{"*": DefaultLogLevel}) {
+		t.Fatalf("unexpected default levels %v", levels)
+	}
+
+	l := Logging{
+		Level:      "WARN",
+		Subsystems: map[string]string{"dht": "debug", "bitswap": "", "core": " Info "},
+	}
+	levels, err = l.Normalized()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"*": "warn", "dht": "debug", "bitswap": "warn", "core": "info"}
+	if !reflect.DeepEqual(levels, expected) {
+		t.Fatalf("expected %v, got %v", expected, levels)
+	}
+	if err := l.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoggingValidate(t *testing.T) {
+	l := Logging{Level: "verbose", Subsystems: map[string]string{"dht": "trace", "core": "info"}}
+	if errs, ok := l.Validate().(ValidationErrors); !ok || len(errs) != 2 {
+		t.Fatalf("expected two validation errors, got %v", l.Validate())
+	}
+	if _, err := l.Normalized(); err == nil {
+		t.Fatal("expected unknown level to fail normalization")
+	}
+}
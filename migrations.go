@@ -1,6 +1,9 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"reflect"
 	"strings"
 
@@ -268,3 +271,137 @@ func MigrateConfig(cfg *Config, inited, hasHval bool) bool {
 	updated = migrate_16_TrongridDomain(cfg) || updated
 	return updated
 }
+
+// EnsureDefaults fills in required fields that configs written before the
+// field existed leave empty, such as Swarm.ConnMgr.GracePeriod or
+// Reprovider.Interval, and reports whether it changed anything. Fields that
+// are set are never touched, so it is safe to call on every load. err reports
+// the problems left once the defaults are in place, see Validate.
+func (c *Config) EnsureDefaults() (changed bool, err error) {
+	def := NewDefault()
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+			changed = true
+		}
+	}
+
+	fill(&c.Datastore.StorageMax, def.Datastore.StorageMax)
+	fill(&c.Datastore.GCPeriod, def.Datastore.GCPeriod)
+	if c.Datastore.Spec == nil {
+		c.Datastore.Spec = def.Datastore.Spec
+		changed = true
+	}
+
+	fill(&c.Routing.Type, def.Routing.Type)
+	fill(&c.Reprovider.Interval, def.Reprovider.Interval)
+	fill(&c.Reprovider.Strategy, def.Reprovider.Strategy)
+	fill(&c.Ipns.RecordLifetime, def.Ipns.RecordLifetime)
+	fill(&c.Ipns.RepublishPeriod, def.Ipns.RepublishPeriod)
+
+	cm := &c.Swarm.ConnMgr
+	fill(&cm.Type, def.Swarm.ConnMgr.Type)
+	if cm.Type == ConnMgrTypeNone && *cm != (ConnMgr{Type: ConnMgrTypeNone}) {
+		*cm = ConnMgr{Type: ConnMgrTypeNone}
+		changed = true
+	}
+	if cm.Type != ConnMgrTypeNone {
+		fill(&cm.GracePeriod, def.Swarm.ConnMgr.GracePeriod)
+		if cm.LowWater == 0 && cm.HighWater == 0 {
+			cm.LowWater, cm.HighWater = def.Swarm.ConnMgr.LowWater, def.Swarm.ConnMgr.HighWater
+			changed = true
+		}
+	}
+	fill(&c.Swarm.SwarmKey, def.Swarm.SwarmKey)
+
+	return changed, c.Validate()
+}
+
+// CurrentConfigVersion is the config file format version written by this
+// package. Configs without a Version field are version 0.
+const CurrentConfigVersion = 1
+
+// formatMigrations upgrade the raw config file format, formatMigrations[i]
+// migrates version i to version i+1.
+var formatMigrations = []func(m map[string]interface{}) error{
+	migrateFormatV0ToV1,
+}
+
+// migrateFormatV0ToV1 replaces the deprecated Swarm.DisableRelay flag with
+// Swarm.Transports.Network.Relay.
+func migrateFormatV0ToV1(m map[string]interface{}) error {
+	swarm, ok := m["Swarm"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	disable, ok := swarm["DisableRelay"]
+	if !ok {
+		return nil
+	}
+	delete(swarm, "DisableRelay")
+	if disable != true {
+		return nil
+	}
+
+	transports, ok := swarm["Transports"].(map[string]interface{})
+	if !ok {
+		transports = map[string]interface{}{}
+		swarm["Transports"] = transports
+	}
+	network, ok := transports["Network"].(map[string]interface{})
+	if !ok {
+		network = map[string]interface{}{}
+		transports["Network"] = network
+	}
+	// an explicit Relay setting already overrides DisableRelay.
+	if _, ok := network["Relay"]; !ok {
+		network["Relay"] = false
+	}
+	return nil
+}
+
+// Migrate upgrades a raw config file to CurrentConfigVersion, applying every
+// format migration between the file's version and the current one in order.
+// It returns the migrated config along with the version the input was
+// written in, so callers can tell whether it needs to be stored again.
+func Migrate(raw []byte) ([]byte, int, error) {
+	var m map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		return nil, 0, fmt.Errorf("failure to decode config: %s", err)
+	}
+
+	version := 0
+	if v, ok := m["Version"]; ok {
+		n, isNum := v.(json.Number)
+		if !isNum {
+			return nil, 0, fmt.Errorf("invalid config version: %v", v)
+		}
+		i, err := n.Int64()
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid config version: %s", n)
+		}
+		version = int(i)
+	}
+	if version < 0 || version > CurrentConfigVersion {
+		return nil, version, fmt.Errorf("unsupported config version %d, this build supports up to %d",
+			version, CurrentConfigVersion)
+	}
+	if version == CurrentConfigVersion {
+		return raw, version, nil
+	}
+
+	for v := version; v < CurrentConfigVersion; v++ {
+		if err := formatMigrations[v](m); err != nil {
+			return nil, version, fmt.Errorf("failed to migrate config from version %d to %d: %s", v, v+1, err)
+		}
+	}
+	m["Version"] = CurrentConfigVersion
+
+	out, err := Marshal(m)
+	if err != nil {
+		return nil, version, err
+	}
+	return out, version, nil
+}
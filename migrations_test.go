@@ -0,0 +1,71 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	v0 := []byte(`{"Identity":{"PeerID":"faketest"},"Swarm":{"DisableRelay":true,"ConnMgr":{"HighWater":900}}}`)
+	out, version, err := Migrate(v0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 0 {
+		t.Fatalf("expected input version 0, got %d", version)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(out, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != CurrentConfigVersion {
+		t.Fatalf("expected version %d, got %d", CurrentConfigVersion, cfg.Version)
+	}
+	if cfg.Swarm.DisableRelay || cfg.Swarm.Transports.Network.Relay != False {
+		t.Fatalf("expected DisableRelay to move to Transports.Network.Relay, got %s", out)
+	}
+	if cfg.Identity.PeerID != "faketest" || cfg.Swarm.ConnMgr.HighWater != 900 {
+		t.Fatal("expected unrelated fields to be preserved")
+	}
+
+	again, version, err := Migrate(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != CurrentConfigVersion || string(again) != string(out) {
+		t.Fatal("expected current config to be left alone")
+	}
+
+	if _, _, err := Migrate([]byte(`{"Version": 99}`)); err == nil {
+		t.Fatal("expected newer config version to be rejected")
+	}
+}
+
+func TestEnsureDefaults(t *testing.T) {
+	var cfg Config
+	if err := json.Unmarshal([]byte(`{"Swarm":{"ConnMgr":{"Type":"basic","LowWater":10,"HighWater":50}},"Reprovider":{}}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := cfg.EnsureDefaults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Fatal("expected missing fields to be filled in")
+	}
+	if cfg.Swarm.ConnMgr.GracePeriod != DefaultConnMgrGracePeriod.String() {
+		t.Fatalf("expected default grace period, got %q", cfg.Swarm.ConnMgr.GracePeriod)
+	}
+	if cfg.Reprovider.Interval != "12h" || cfg.Reprovider.Strategy != ReproviderStrategyAll {
+		t.Fatalf("expected default reprovider, got %+v", cfg.Reprovider)
+	}
+	if cfg.Swarm.ConnMgr.LowWater != 10 || cfg.Swarm.ConnMgr.HighWater != 50 {
+		t.Fatal("expected configured water marks to be kept")
+	}
+
+	changed, err = cfg.EnsureDefaults()
+	if err != nil || changed {
+		t.Fatalf("expected second call to be a no-op, got %v, %v", changed, err)
+	}
+}
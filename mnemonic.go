@@ -0,0 +1,104 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	ci "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// TronDerivationPath is the BIP44 path used by TRON wallets, m/44'/195'/0'/0/0.
+var TronDerivationPath = []uint32{
+	44 + bip32HardenedOffset,
+	195 + bip32HardenedOffset,
+	0 + bip32HardenedOffset,
+	0,
+	0,
+}
+
+const bip32HardenedOffset = 0x80000000
+
+var bip32MasterKey = []byte("Bitcoin seed")
+
+// ErrInvalidMnemonic is returned when a mnemonic phrase fails validation.
+var ErrInvalidMnemonic = errors.New("invalid mnemonic phrase")
+
+// IdentityFromMnemonic derives a Secp256k1 identity from a BIP39 mnemonic
+// phrase along TronDerivationPath. The resulting identity is the same one
+// importKey would produce for the derived TRON private key.
+func IdentityFromMnemonic(out io.Writer, mnemonic string, passphrase string) (Identity, error) {
+	words := strings.Fields(mnemonic)
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return Identity{}, fmt.Errorf("%w: expected 12, 15, 18, 21 or 24 words, got %d",
+			ErrInvalidMnemonic, len(words))
+	}
+	mnemonic = strings.Join(words, " ")
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrInvalidMnemonic, err)
+	}
+	skBytes, err := deriveBip32Key(seed, TronDerivationPath)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	fmt.Fprintf(out, "generating btfs node keypair with mnemonic...")
+	sk, err := ci.UnmarshalSecp256k1PrivateKey(skBytes)
+	if err != nil {
+		return Identity{}, err
+	}
+	fmt.Fprintf(out, "done\n")
+	return identityFromPrivKey(out, sk, mnemonic)
+}
+
+// deriveBip32Key derives the private key at path from a BIP32 seed.
+func deriveBip32Key(seed []byte, path []uint32) ([]byte, error) {
+	mac := hmac.New(sha512.New, bip32MasterKey)
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+
+	curve := btcec.S256()
+	for _, index := range path {
+		var data []byte
+		if index >= bip32HardenedOffset {
+			data = append([]byte{0x0}, key...)
+		} else {
+			_, pub := btcec.PrivKeyFromBytes(curve, key)
+			data = pub.SerializeCompressed()
+		}
+		var ib [4]byte
+		binary.BigEndian.PutUint32(ib[:], index)
+		data = append(data, ib[:]...)
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		il := new(big.Int).SetBytes(sum[:32])
+		if il.Cmp(curve.N) >= 0 {
+			return nil, fmt.Errorf("invalid derived key at index %d", index)
+		}
+		child := il.Add(il, new(big.Int).SetBytes(key))
+		child.Mod(child, curve.N)
+		if child.Sign() == 0 {
+			return nil, fmt.Errorf("invalid derived key at index %d", index)
+		}
+
+		key = make([]byte, 32)
+		b := child.Bytes()
+		copy(key[32-len(b):], b)
+		chainCode = sum[32:]
+	}
+	return key, nil
+}
@@ -1,8 +1,49 @@
 package config
 
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+)
+
 // Mounts stores the (string) mount points
 type Mounts struct {
 	IPFS           string
 	IPNS           string
 	FuseAllowOther bool
 }
+
+// Validate checks that the FUSE mount points are distinct, absolute and not
+// the filesystem root. A config without any mount point is left alone.
+func (m Mounts) Validate() error {
+	if m.IPFS == "" && m.IPNS == "" {
+		return nil
+	}
+	var errs ValidationErrors
+	for _, f := range []struct {
+		name, path string
+	}{
+		{"IPFS", m.IPFS},
+		{"IPNS", m.IPNS},
+	} {
+		if err := validateMountpoint(f.path); err != nil {
+			errs.add(fieldErrorf("Mounts."+f.name, "%s", err))
+		}
+	}
+	if m.IPFS != "" && filepath.Clean(m.IPFS) == filepath.Clean(m.IPNS) {
+		errs.add(fieldErrorf("Mounts", "IPFS and IPNS must be mounted at different paths, both are %q", m.IPFS))
+	}
+	return errs.errOrNil()
+}
+
+func validateMountpoint(p string) error {
+	switch {
+	case p == "":
+		return fmt.Errorf("mount point must not be empty")
+	case !path.IsAbs(p) && !filepath.IsAbs(p):
+		return fmt.Errorf("mount point %q must be an absolute path", p)
+	case filepath.Dir(filepath.Clean(p)) == filepath.Clean(p):
+		return fmt.Errorf("mount point %q must not be the filesystem root", p)
+	}
+	return nil
+}
@@ -3,7 +3,43 @@ package config
 import "github.com/libp2p/go-libp2p-core/peer"
 
 // Peering configures the peering service.
+//
+// Peering peers are protected from connection manager trimming and are
+// reconnected whenever the connection drops.
 type Peering struct {
 	// Peers lists the nodes to attempt to stay connected with.
 	Peers []peer.AddrInfo
 }
+
+// AddPeeringPeer adds ai to the peering list. If the peer is already listed,
+// its addresses are merged into the existing entry.
+func (c *Config) AddPeeringPeer(ai peer.AddrInfo) {
+	for i, p := range c.Peering.Peers {
+		if p.ID != ai.ID {
+			continue
+		}
+		known := make(map[string]struct{}, len(p.Addrs))
+		for _, addr := range p.Addrs {
+			known[addr.String()] = struct{}{}
+		}
+		for _, addr := range ai.Addrs {
+			if _, ok := known[addr.String()]; !ok {
+				c.Peering.Peers[i].Addrs = append(c.Peering.Peers[i].Addrs, addr)
+			}
+		}
+		return
+	}
+	c.Peering.Peers = append(c.Peering.Peers, ai)
+}
+
+// RemovePeeringPeer removes the peer with the given ID from the peering list.
+// It reports whether the peer was listed.
+func (c *Config) RemovePeeringPeer(id peer.ID) bool {
+	for i, p := range c.Peering.Peers {
+		if p.ID == id {
+			c.Peering.Peers = append(c.Peering.Peers[:i:i], c.Peering.Peers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// Pinning configures remote pinning services.
+type Pinning struct {
+	RemoteServices map[string]RemotePinningService
+}
+
+// RemotePinningService is a remote pinning service and how it is used.
+type RemotePinningService struct {
+	// API configures how to reach the pinning service.
+	API RemotePinningServiceAPI
+	// Policies configures what is pinned automatically.
+	Policies RemotePinningServicePolicies
+}
+
+// RemotePinningServiceAPI holds the endpoint of a pinning service and its
+// access token.
+type RemotePinningServiceAPI struct {
+	Endpoint string
+	Key      string
+}
+
+// RemotePinningServicePolicies holds the automatic pinning policies.
+type RemotePinningServicePolicies struct {
+	MFS RemotePinningServiceMFSPolicy
+}
+
+// RemotePinningServiceMFSPolicy keeps the MFS root pinned on the service.
+type RemotePinningServiceMFSPolicy struct {
+	// Enable enables the policy.
+	Enable bool
+	// PinName is the name of the pin on the service.
+	PinName string
+	// RepinInterval determines how often to check for changes to the MFS
+	// root and repin it.
+	RepinInterval string
+}
+
+// AddRemotePinningService registers a remote pinning service under name.
+// The endpoint must be an http(s) URL and name must not be in use yet.
+func (c *Config) AddRemotePinningService(name, endpoint, key string) error {
+	if name == "" {
+		return fmt.Errorf("remote pinning service name cannot be empty")
+	}
+	if _, ok := c.Pinning.RemoteServices[name]; ok {
+		return fmt.Errorf("remote pinning service %q already exists", name)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid remote pinning service endpoint %q: %s", endpoint, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid remote pinning service endpoint %q: not an http(s) URL", endpoint)
+	}
+
+	if c.Pinning.RemoteServices == nil {
+		c.Pinning.RemoteServices = map[string]RemotePinningService{}
+	}
+	c.Pinning.RemoteServices[name] = RemotePinningService{
+		API: RemotePinningServiceAPI{
+			Endpoint: endpoint,
+			Key:      key,
+		},
+	}
+	return nil
+}
+
+// Validate checks that every remote pinning service has an http(s) endpoint
+// and a parseable RepinInterval.
+func (p Pinning) Validate() error {
+	var errs ValidationErrors
+	names := make([]string, 0, len(p.RemoteServices))
+	for name := range p.RemoteServices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		svc := p.RemoteServices[name]
+		path := "Pinning.RemoteServices." + name
+		if u, err := url.Parse(svc.API.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.add(fieldErrorf(path+".API.Endpoint", "%q is not an http(s) URL", svc.API.Endpoint))
+		}
+		if interval := svc.Policies.MFS.RepinInterval; interval != "" {
+			if _, err := time.ParseDuration(interval); err != nil {
+				errs.add(fieldErrorf(path+".Policies.MFS.RepinInterval", "%s", err))
+			}
+		}
+	}
+	return errs.errOrNil()
+}
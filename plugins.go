@@ -9,3 +9,10 @@ type Plugin struct {
 	Disabled bool
 	Config   interface{}
 }
+
+// IsEnabled reports whether the named plugin may be loaded. Plugins are
+// enabled unless explicitly disabled.
+func (p Plugins) IsEnabled(name string) bool {
+	plugin, ok := p.Plugins[name]
+	return !ok || !plugin.Disabled
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPlugins(t *testing.T) {
+	in := Plugins{Plugins: map[string]Plugin{
+		"ds-s3": {Config: map[string]interface{}{
+			"bucket":  "btfs",
+			"region":  map[string]interface{}{"name": "us-west-2", "zones": []interface{}{"a", "b"}},
+			"retries": float64(3),
+		}},
+		"fuse": {Disabled: true},
+	}}
+	out, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Plugins
+	if err := json.Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, in) {
+		t.Fatalf("expected plugins to round trip, got %s", out)
+	}
+
+	if !back.IsEnabled("ds-s3") || back.IsEnabled("fuse") || !back.IsEnabled("unknown") {
+		t.Fatal("unexpected plugin enabled state")
+	}
+}
@@ -92,14 +92,24 @@ func ExternalIPWithPort(extPort, intPort int, swarmAddrs []string) (string, erro
 // Profiles is a map holding configuration transformers. Docs are in docs/config.md
 var Profiles = map[string]Profile{
 	"server": {
-		Description: `Disables local host discovery, recommended when
-running IPFS on machines with public IPv4 addresses.`,
+		Description: `Disables local host discovery and raises the connection
+manager limits, recommended when running IPFS on machines with public IPv4
+addresses.`,
 
 		Transform: func(c *Config) error {
 			c.Addresses.NoAnnounce = appendSingle(c.Addresses.NoAnnounce, defaultServerFilters)
 			c.Swarm.AddrFilters = appendSingle(c.Swarm.AddrFilters, defaultServerFilters)
 			c.Discovery.MDNS.Enabled = false
 			c.Swarm.DisableNatPortMap = true
+
+			// servers have the resources for more connections, only
+			// ever raise the water marks.
+			if c.Swarm.ConnMgr.LowWater < ServerConnMgrLowWater {
+				c.Swarm.ConnMgr.LowWater = ServerConnMgrLowWater
+			}
+			if c.Swarm.ConnMgr.HighWater < ServerConnMgrHighWater {
+				c.Swarm.ConnMgr.HighWater = ServerConnMgrHighWater
+			}
 			return nil
 		},
 	},
@@ -118,8 +128,13 @@ profile, enables discovery in local networks.`,
 	},
 	"test": {
 		Description: `Reduces external interference of IPFS daemon, this
-is useful when using the daemon in test environments.`,
+is useful when using the daemon in test environments. Every listener uses a
+random loopback port and the node neither bootstraps, discovers peers nor
+routes.
+
+This profile may only be applied when first initializing the node.`,
 
+		InitOnly: true,
 		Transform: func(c *Config) error {
 			c.Addresses.API = Strings{"/ip4/127.0.0.1/tcp/0"}
 			c.Addresses.Gateway = Strings{"/ip4/127.0.0.1/tcp/0"}
@@ -131,6 +146,7 @@ is useful when using the daemon in test environments.`,
 
 			c.Bootstrap = []string{}
 			c.Discovery.MDNS.Enabled = false
+			c.Routing.Type = RoutingTypeNone
 			return nil
 		},
 	},
@@ -149,6 +165,7 @@ Inverse profile of the test profile.`,
 
 			c.Swarm.DisableNatPortMap = false
 			c.Discovery.MDNS.Enabled = true
+			c.Routing.Type = DefaultRouting().Type
 			return nil
 		},
 	},
@@ -229,10 +246,11 @@ functionality - performance of content discovery and data
 fetching may be degraded.
 `,
 		Transform: func(c *Config) error {
-			c.Routing.Type = "dhtclient"
+			c.Routing.Type = RoutingTypeDHTClient
 			c.AutoNAT.ServiceMode = AutoNATServiceDisabled
 			c.Reprovider.Interval = "0"
 
+			c.Swarm.ConnMgr.Type = ConnMgrTypeBasic
 			c.Swarm.ConnMgr.LowWater = 20
 			c.Swarm.ConnMgr.HighWater = 40
 			c.Swarm.ConnMgr.GracePeriod = time.Minute.String()
@@ -405,6 +423,28 @@ fetching may be degraded.
 	},
 }
 
+// ApplyProfiles applies the named profiles to the config, in order. Profiles
+// marked InitOnly are rejected, pass them to Init via WithProfiles instead.
+func (c *Config) ApplyProfiles(names []string) error {
+	return c.applyProfiles(names, false)
+}
+
+func (c *Config) applyProfiles(names []string, initializing bool) error {
+	for _, name := range names {
+		profile, ok := Profiles[name]
+		if !ok {
+			return fmt.Errorf("invalid configuration profile: %s", name)
+		}
+		if profile.InitOnly && !initializing {
+			return fmt.Errorf("profile %s can only be applied on init", name)
+		}
+		if err := profile.Transform(c); err != nil {
+			return fmt.Errorf("failed to apply profile %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
 func transformDefaultStorageHost(c *Config) error {
 	bootstrapPeers, err := DefaultBootstrapPeers()
 	if err != nil {
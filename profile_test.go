@@ -0,0 +1,107 @@
+package config
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestServerProfileIdempotent(t *testing.T) {
+	c := new(Config)
+	c.Discovery.MDNS.Enabled = true
+	for i := 0; i < 2; i++ {
+		if err := c.ApplyProfiles([]string{"server"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.Discovery.MDNS.Enabled {
+		t.Fatal("expected server profile to disable MDNS")
+	}
+
+	counts := map[string]int{}
+	for _, f := range c.Addresses.NoAnnounce {
+		counts[f]++
+	}
+	for _, f := range []string{
+		"/ip4/10.0.0.0/ipcidr/8",
+		"/ip4/172.16.0.0/ipcidr/12",
+		"/ip4/192.168.0.0/ipcidr/16",
+		"/ip4/100.64.0.0/ipcidr/10",
+		"/ip6/fc00::/ipcidr/7",
+	} {
+		if counts[f] != 1 {
+			t.Fatalf("expected %s exactly once in NoAnnounce, found %d times", f, counts[f])
+		}
+	}
+	if len(c.Addresses.NoAnnounce) != len(defaultServerFilters) {
+		t.Fatalf("expected %d NoAnnounce entries, got %d", len(defaultServerFilters), len(c.Addresses.NoAnnounce))
+	}
+}
+
+func TestConnMgrProfiles(t *testing.T) {
+	c := new(Config)
+	c.Swarm.ConnMgr = ConnMgr{
+		Type:        "basic",
+		LowWater:    DefaultConnMgrLowWater,
+		HighWater:   DefaultConnMgrHighWater,
+		GracePeriod: DefaultConnMgrGracePeriod.String(),
+	}
+	c.Reprovider.Interval = "12h"
+
+	if err := c.ApplyProfiles([]string{"lowpower"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := ConnMgr{Type: "basic", LowWater: 20, HighWater: 40, GracePeriod: "1m0s"}
+	if c.Swarm.ConnMgr != expected {
+		t.Fatalf("expected conn mgr %+v, got %+v", expected, c.Swarm.ConnMgr)
+	}
+	if c.Reprovider.Interval != "0" {
+		t.Fatalf("expected reprovider to be disabled, got %s", c.Reprovider.Interval)
+	}
+
+	if err := c.ApplyProfiles([]string{"server"}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Swarm.ConnMgr.LowWater < ServerConnMgrLowWater || c.Swarm.ConnMgr.HighWater < ServerConnMgrHighWater {
+		t.Fatalf("expected server profile to raise the water marks, got %+v", c.Swarm.ConnMgr)
+	}
+	if err := c.Swarm.ConnMgr.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTestProfile(t *testing.T) {
+	c, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false, WithProfiles("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	loopback := "/ip4/127.0.0.1/tcp/0"
+	if !reflect.DeepEqual(c.Addresses.Swarm, []string{loopback}) {
+		t.Fatalf("unexpected swarm addresses %v", c.Addresses.Swarm)
+	}
+	if !reflect.DeepEqual(c.Addresses.API, Strings{loopback}) || !reflect.DeepEqual(c.Addresses.Gateway, Strings{loopback}) {
+		t.Fatalf("unexpected API or gateway addresses %v, %v", c.Addresses.API, c.Addresses.Gateway)
+	}
+	if c.Bootstrap == nil || len(c.Bootstrap) != 0 {
+		t.Fatalf("expected empty bootstrap list, got %v", c.Bootstrap)
+	}
+	if c.Discovery.MDNS.Enabled {
+		t.Fatal("expected MDNS to be disabled")
+	}
+	if c.Routing.Type != RoutingTypeNone {
+		t.Fatalf("expected routing type %q, got %q", RoutingTypeNone, c.Routing.Type)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.ApplyProfiles([]string{"test"}); err == nil {
+		t.Fatal("expected test profile to be init only")
+	}
+	if err := c.ApplyProfiles([]string{"default-networking"}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Routing.Type != RoutingTypeDHT {
+		t.Fatalf("expected default-networking to restore routing type, got %q", c.Routing.Type)
+	}
+}
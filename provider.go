@@ -1,5 +1,65 @@
 package config
 
+import "time"
+
+// DefaultProviderWorkerCount is the number of concurrent provide operations
+// used when Provider.WorkerCount is unset.
+const DefaultProviderWorkerCount = 16
+
+// Provider configures the announcement of newly added content. The
+// periodic sweep over already stored content is configured by Reprovider.
 type Provider struct {
 	Strategy string // Which keys to announce
+
+	// Provide disables announcing new content when set to False. Defaults
+	// to on.
+	Provide Flag `json:",omitempty"`
+
+	// WorkerCount caps the number of concurrent provide operations. Zero
+	// means DefaultProviderWorkerCount.
+	WorkerCount int `json:",omitempty"`
+
+	// DelayBetween is the pause between two provide operations of a single
+	// worker, e.g. "100ms".
+	DelayBetween string `json:",omitempty"`
+}
+
+// Enabled reports whether newly added content gets announced.
+func (p Provider) Enabled() bool {
+	return p.Provide.WithDefault(true)
+}
+
+// Workers returns the effective number of provide workers.
+func (p Provider) Workers() int {
+	if p.WorkerCount == 0 {
+		return DefaultProviderWorkerCount
+	}
+	return p.WorkerCount
+}
+
+// Validate checks the provider strategy, worker count and delay. It accepts
+// the same strategies as Reprovider.
+func (p Provider) Validate() error {
+	var errs ValidationErrors
+	switch p.Strategy {
+	case "", ReproviderStrategyAll, ReproviderStrategyPinned, ReproviderStrategyRoots:
+	default:
+		errs.add(fieldErrorf("Provider.Strategy", "unknown strategy %q, must be one of %q, %q or %q", p.Strategy, ReproviderStrategyAll, ReproviderStrategyPinned, ReproviderStrategyRoots))
+	}
+	switch p.Provide {
+	case Default, True, False:
+	default:
+		errs.add(fieldErrorf("Provider.Provide", "invalid flag value %d", p.Provide))
+	}
+	if p.WorkerCount < 0 {
+		errs.add(fieldErrorf("Provider.WorkerCount", "must not be negative, got %d", p.WorkerCount))
+	}
+	if p.DelayBetween != "" {
+		if d, err := time.ParseDuration(p.DelayBetween); err != nil {
+			errs.add(fieldErrorf("Provider.DelayBetween", "%s", err))
+		} else if d < 0 {
+			errs.add(fieldErrorf("Provider.DelayBetween", "must not be negative, got %s", p.DelayBetween))
+		}
+	}
+	return errs.errOrNil()
 }
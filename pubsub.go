@@ -1,5 +1,14 @@
 package config
 
+import "time"
+
+const (
+	// PubsubRouterGossipsub is the default pubsub router.
+	PubsubRouterGossipsub = "gossipsub"
+	// PubsubRouterFloodsub is the legacy pubsub router.
+	PubsubRouterFloodsub = "floodsub"
+)
+
 type PubsubConfig struct {
 	// Router can be either floodsub (legacy) or gossipsub (new and
 	// backwards compatible).
@@ -8,4 +17,24 @@ type PubsubConfig struct {
 	// DisableSigning disables message signing. Message signing is *enabled*
 	// by default.
 	DisableSigning bool
+
+	// SeenMessagesTTL configures how long a message id is remembered to
+	// detect duplicates. When unset, the router default is used.
+	SeenMessagesTTL string `json:",omitempty"`
+}
+
+// Validate checks the pubsub router and seen messages TTL.
+func (p PubsubConfig) Validate() error {
+	var errs ValidationErrors
+	switch p.Router {
+	case "", PubsubRouterGossipsub, PubsubRouterFloodsub:
+	default:
+		errs.add(fieldErrorf("Pubsub.Router", "unknown router %q", p.Router))
+	}
+	if p.SeenMessagesTTL != "" {
+		if _, err := time.ParseDuration(p.SeenMessagesTTL); err != nil {
+			errs.add(fieldErrorf("Pubsub.SeenMessagesTTL", "%s", err))
+		}
+	}
+	return errs.errOrNil()
 }
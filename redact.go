@@ -0,0 +1,102 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RedactedValue replaces sensitive values in MarshalRedacted output.
+const RedactedValue = "<redacted>"
+
+// SensitivePaths lists the dot separated config paths that hold secrets and
+// must never show up in logs or bug reports. A "*" segment matches every key
+// of a map.
+var SensitivePaths = []string{
+	PrivKeySelector,
+	MnemonicSelector,
+	IdentityTag + ".EncryptedPrivKey",
+	IdentityTag + ".EncryptedMnemonic",
+	"Identities.*." + PrivKeyTag,
+	"Identities.*." + MnemonicTag,
+	"Identities.*.EncryptedPrivKey",
+	"Identities.*.EncryptedMnemonic",
+	"Pinning.RemoteServices.*.API.Key",
+	"API.Authorizations.*.AuthSecret",
+}
+
+// MarshalRedacted marshals a copy of the config with every value listed in
+// SensitivePaths replaced by RedactedValue.
+func (c *Config) MarshalRedacted() ([]byte, error) {
+	m, err := c.redactedMap()
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(m)
+}
+
+// redactedMap returns the config as a generic map with every value listed in
+// SensitivePaths replaced.
+func (c *Config) redactedMap() (map[string]interface{}, error) {
+	m, err := c.ToMap()
+	if err != nil {
+		return nil, err
+	}
+	redactMap(m, nil)
+	return m, nil
+}
+
+// GoString formats the config as redacted, key sorted JSON so that %#v is
+// safe to use in logs and test failures.
+func (c Config) GoString() string {
+	m, err := c.redactedMap()
+	if err != nil {
+		return fmt.Sprintf("config.Config{<%s>}", err)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return fmt.Sprintf("config.Config{<%s>}", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// redactMap replaces every set value of m, found at prefix, whose path is
+// sensitive.
+func redactMap(m map[string]interface{}, prefix []string) {
+	for key, value := range m {
+		path := append(prefix[:len(prefix):len(prefix)], key)
+		if isSensitivePath(path) {
+			if s, isStr := value.(string); value == nil || isStr && s == "" {
+				continue
+			}
+			m[key] = RedactedValue
+			continue
+		}
+		if next, ok := value.(map[string]interface{}); ok {
+			redactMap(next, path)
+		}
+	}
+}
+
+// isSensitivePath reports whether path is, or is below, one of the
+// SensitivePaths.
+func isSensitivePath(path []string) bool {
+next:
+	for _, sensitive := range SensitivePaths {
+		parts := strings.Split(sensitive, ".")
+		if len(path) < len(parts) {
+			continue
+		}
+		for i, part := range parts {
+			if part != "*" && !strings.EqualFold(part, path[i]) {
+				continue next
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -1,6 +1,35 @@
 package config
 
+import "time"
+
+// Reprovider strategies select which keys are announced to the network.
+const (
+	// ReproviderStrategyAll announces every locally stored block.
+	ReproviderStrategyAll = "all"
+	// ReproviderStrategyPinned announces only pinned data, recursively.
+	ReproviderStrategyPinned = "pinned"
+	// ReproviderStrategyRoots announces only the roots of pinned data.
+	ReproviderStrategyRoots = "roots"
+)
+
 type Reprovider struct {
 	Interval string // Time period to reprovide locally stored objects to the network
 	Strategy string // Which keys to announce
 }
+
+// Validate checks the reprovider interval and strategy. An Interval of "0"
+// disables reproviding.
+func (r Reprovider) Validate() error {
+	var errs ValidationErrors
+	if r.Interval != "" {
+		if _, err := time.ParseDuration(r.Interval); err != nil {
+			errs.add(fieldErrorf("Reprovider.Interval", "%s", err))
+		}
+	}
+	switch r.Strategy {
+	case "", ReproviderStrategyAll, ReproviderStrategyPinned, ReproviderStrategyRoots:
+	default:
+		errs.add(fieldErrorf("Reprovider.Strategy", "unknown strategy %q, must be one of %q, %q or %q", r.Strategy, ReproviderStrategyAll, ReproviderStrategyPinned, ReproviderStrategyRoots))
+	}
+	return errs.errOrNil()
+}
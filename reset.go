@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// resettableSections maps the sections accepted by ResetSection to the
+// function restoring their defaults.
+var resettableSections = map[string]func(c *Config) error{
+	"Addresses": func(c *Config) error {
+		c.Addresses = addressesConfig()
+		return nil
+	},
+	"Bootstrap": (*Config).SetBootstrapToDefault,
+	"Datastore": func(c *Config) error {
+		c.Datastore = DefaultDatastoreConfig()
+		return nil
+	},
+	"Discovery": func(c *Config) error {
+		c.Discovery = DefaultDiscovery()
+		return nil
+	},
+	"Gateway": func(c *Config) error {
+		c.Gateway = DefaultGateway()
+		return nil
+	},
+	"Reprovider": func(c *Config) error {
+		c.Reprovider = DefaultReprovider()
+		return nil
+	},
+	"Swarm": func(c *Config) error {
+		key := c.Swarm.SwarmKey
+		c.Swarm = DefaultSwarmConfig()
+		c.Swarm.SwarmKey = key
+		return nil
+	},
+}
+
+// ResetSection restores a single top level section, e.g. "Datastore", to its
+// defaults and leaves the rest of the config untouched. Names are matched
+// case insensitively. Resetting Swarm keeps Swarm.SwarmKey, as it selects the
+// network the node belongs to.
+func (c *Config) ResetSection(name string) error {
+	for section, reset := range resettableSections {
+		if strings.EqualFold(section, name) {
+			return reset(c)
+		}
+	}
+	return fmt.Errorf("cannot reset unknown config section %q, must be one of %s", name, strings.Join(ResettableSections(), ", "))
+}
+
+// ResettableSections returns the sorted names accepted by ResetSection.
+func ResettableSections() []string {
+	names := make([]string, 0, len(resettableSections))
+	for name := range resettableSections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
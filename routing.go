@@ -1,9 +1,133 @@
 package config
 
+import (
+	"net/url"
+	"sort"
+)
+
+// Routing types accepted in Routing.Type.
+const (
+	// RoutingTypeDHT serves the DHT only once the node is publicly reachable.
+	RoutingTypeDHT = "dht"
+	// RoutingTypeDHTClient queries the DHT but never serves it.
+	RoutingTypeDHTClient = "dhtclient"
+	// RoutingTypeDHTServer always serves the DHT, even when unreachable.
+	RoutingTypeDHTServer = "dhtserver"
+	// RoutingTypeAuto leaves the choice of routing mode to the daemon.
+	RoutingTypeAuto = "auto"
+	// RoutingTypeNone disables the DHT, leaving only Routing.Methods.
+	RoutingTypeNone = "none"
+)
+
+// Router types accepted in RouterConfig.Type.
+const (
+	// RouterTypeHTTP delegates routing to a /routing/v1 HTTP endpoint.
+	RouterTypeHTTP = "http"
+	// RouterTypeDHT routes through the local DHT.
+	RouterTypeDHT = "dht"
+)
+
+// Routing method names accepted as keys of Routing.Methods.
+const (
+	MethodFindProviders = "find-providers"
+	MethodFindPeers     = "find-peers"
+	MethodProvide       = "provide"
+	MethodGetIPNS       = "get-ipns"
+	MethodPutIPNS       = "put-ipns"
+)
+
 // Routing defines configuration options for libp2p routing
 type Routing struct {
 	// Type sets default daemon routing mode.
 	//
-	// Can be one of "dht", "dhtclient", "dhtserver", "none", or unset.
+	// Can be one of "dht", "dhtclient", "dhtserver", "auto", "none", or
+	// unset. See the RoutingType constants.
 	Type string
+
+	// Routers declares named routers that Methods can delegate to.
+	Routers map[string]RouterConfig `json:",omitempty"`
+
+	// Methods maps a routing method (e.g. "find-providers") to the router
+	// that serves it.
+	Methods map[string]Method `json:",omitempty"`
+}
+
+// RouterConfig configures a single named router.
+type RouterConfig struct {
+	// Type is the router kind, "http" or "dht".
+	Type string
+
+	// Endpoint is the base URL of an http router, e.g.
+	// "https://example.com" for https://example.com/routing/v1.
+	Endpoint string `json:",omitempty"`
+
+	// Parameters holds router specific options.
+	Parameters map[string]string `json:",omitempty"`
+}
+
+// Method selects the router used for a routing method.
+type Method struct {
+	// RouterName is the key of the router in Routing.Routers.
+	RouterName string
+}
+
+// Validate checks the routing type, the declared routers and the methods
+// referring to them.
+func (r Routing) Validate() error {
+	var errs ValidationErrors
+	switch r.Type {
+	case "", RoutingTypeDHT, RoutingTypeDHTClient, RoutingTypeDHTServer, RoutingTypeAuto, RoutingTypeNone:
+	default:
+		errs.add(fieldErrorf("Routing.Type", "unknown routing type %q", r.Type))
+	}
+
+	for _, name := range sortedRouterNames(r.Routers) {
+		errs.add(r.Routers[name].validate(name))
+	}
+
+	methods := make([]string, 0, len(r.Methods))
+	for method := range r.Methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		m := r.Methods[method]
+		switch method {
+		case MethodFindProviders, MethodFindPeers, MethodProvide, MethodGetIPNS, MethodPutIPNS:
+		default:
+			errs.add(fieldErrorf("Routing.Methods", "unknown method %q", method))
+			continue
+		}
+		if _, ok := r.Routers[m.RouterName]; !ok {
+			errs.add(fieldErrorf("Routing.Methods."+method, "unknown router %q", m.RouterName))
+		}
+	}
+	return errs.errOrNil()
+}
+
+func (rc RouterConfig) validate(name string) error {
+	switch rc.Type {
+	case RouterTypeDHT:
+		return nil
+	case RouterTypeHTTP:
+		u, err := url.Parse(rc.Endpoint)
+		if err != nil {
+			return fieldErrorf("Routing.Routers."+name+".Endpoint", "%s", err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fieldErrorf("Routing.Routers."+name+".Endpoint", "%q is not an http(s) URL", rc.Endpoint)
+		}
+		return nil
+	default:
+		return fieldErrorf("Routing.Routers."+name+".Type", "unknown router type %q", rc.Type)
+	}
+}
+
+func sortedRouterNames(routers map[string]RouterConfig) []string {
+	names := make([]string, 0, len(routers))
+	for name := range routers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
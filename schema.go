@@ -0,0 +1,163 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// JSONSchemaDraft is the JSON Schema version emitted by ConfigJSONSchema.
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// schemaEnums lists the values accepted by string fields restricted to a set
+// of constants, keyed by dot separated config path. The empty string stands
+// for the default, as in Validate.
+var schemaEnums = map[string][]string{
+	"Routing.Type":        {"", RoutingTypeDHT, RoutingTypeDHTClient, RoutingTypeDHTServer, RoutingTypeAuto, RoutingTypeNone},
+	"Reprovider.Strategy": {"", ReproviderStrategyAll, ReproviderStrategyPinned, ReproviderStrategyRoots},
+	"Provider.Strategy":   {"", ReproviderStrategyAll, ReproviderStrategyPinned, ReproviderStrategyRoots},
+	"Swarm.ConnMgr.Type":  {"", ConnMgrTypeBasic, ConnMgrTypeNone},
+	"Pubsub.Router":       {"", PubsubRouterGossipsub, PubsubRouterFloodsub},
+	"AutoNAT.ServiceMode": {"", "enabled", "disabled"},
+}
+
+var (
+	stringsType       = reflect.TypeOf(Strings{})
+	flagType          = reflect.TypeOf(Flag(0))
+	priorityType      = reflect.TypeOf(Priority(0))
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// ConfigJSONSchema returns a draft-07 JSON Schema describing the config file,
+// generated from the Config struct and its json tags. Fields always written
+// by encoding/json, i.e. those without omitempty, are listed as required.
+// String fields restricted to a set of constants carry an enum.
+func ConfigJSONSchema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeOf(Config{}), "")
+	schema["$schema"] = JSONSchemaDraft
+	schema["title"] = "BTFS config"
+	return Marshal(schema)
+}
+
+// typeSchema returns the schema of t, found at path in the config.
+func typeSchema(t reflect.Type, path string) map[string]interface{} {
+	if enum, ok := schemaEnums[path]; ok {
+		return map[string]interface{}{"type": "string", "enum": enum}
+	}
+	switch t {
+	case stringsType:
+		// a single string is accepted too, see Strings.
+		return map[string]interface{}{
+			"type":  []string{"array", "string"},
+			"items": map[string]interface{}{"type": "string"},
+		}
+	case flagType:
+		return map[string]interface{}{"type": []string{"boolean", "null"}}
+	case priorityType:
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "integer", "minimum": 1},
+				map[string]interface{}{"enum": []interface{}{false, nil}},
+			},
+		}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+	if t.Implements(textMarshalerType) {
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := typeSchema(t.Elem(), path)
+		if typ, ok := s["type"].(string); ok {
+			s["type"] = []string{typ, "null"}
+		}
+		return s
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes byte slices as base64 strings.
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{
+			"type":  []string{"array", "null"},
+			"items": typeSchema(t.Elem(), path+".*"),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 []string{"object", "null"},
+			"additionalProperties": typeSchema(t.Elem(), path+".*"),
+		}
+	case reflect.Struct:
+		return structSchema(t, path)
+	default:
+		// interface{} values, e.g. in Datastore.Spec, can hold anything.
+		return map[string]interface{}{}
+	}
+}
+
+func structSchema(t reflect.Type, path string) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name, omitempty, skip := jsonFieldName(f)
+		if skip {
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			embedded := structSchema(f.Type, path)
+			for k, v := range embedded["properties"].(map[string]interface{}) {
+				properties[k] = v
+			}
+			required = append(required, embedded["required"].([]string)...)
+			continue
+		}
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		properties[name] = typeSchema(f.Type, fieldPath)
+		if !omitempty {
+			required = append(required, name)
+		}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// jsonFieldName returns the key encoding/json uses for f.
+func jsonFieldName(f reflect.StructField) (name string, omitempty, skip bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = f.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestConfigJSONSchema(t *testing.T) {
+	out, err := ConfigJSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(out, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %s", err)
+	}
+	if schema["$schema"] != JSONSchemaDraft || schema["type"] != "object" {
+		t.Fatalf("unexpected schema header %v", schema["$schema"])
+	}
+
+	prop := func(s map[string]interface{}, name string) map[string]interface{} {
+		p, ok := s["properties"].(map[string]interface{})[name].(map[string]interface{})
+		if !ok {
+			t.Fatalf("expected property %s in schema", name)
+		}
+		return p
+	}
+	routingType := prop(prop(schema, "Routing"), "Type")
+	expected := []interface{}{"", "dht", "dhtclient", "dhtserver", "auto", "none"}
+	if !reflect.DeepEqual(routingType["enum"], expected) {
+		t.Fatalf("expected Routing.Type enum %v, got %v", expected, routingType["enum"])
+	}
+	if prop(prop(schema, "Swarm"), "ConnMgr")["required"] == nil {
+		t.Fatal("expected nested structs to list required fields")
+	}
+	if _, ok := schema["properties"].(map[string]interface{})["Extra"]; ok {
+		t.Fatal("expected fields tagged json:\"-\" to be left out")
+	}
+	if prop(prop(schema, "Identity"), "PeerID")["type"] != "string" {
+		t.Fatal("expected Identity.PeerID to be a string")
+	}
+}
@@ -4,13 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"os"
-	"path/filepath"
 
 	"github.com/TRON-US/go-btfs-config"
-
-	"github.com/facebookgo/atomicfile"
 )
 
 // ErrNotInitialized is returned when we fail to read the config because the
@@ -33,40 +30,21 @@ func ReadConfigFile(filename string, cfg interface{}) error {
 	return nil
 }
 
-// WriteConfigFile writes the config from `cfg` into `filename`.
+// WriteConfigFile writes the config from `cfg` into `filename`, see
+// config.WriteFile.
 func WriteConfigFile(filename string, cfg interface{}) error {
-	err := os.MkdirAll(filepath.Dir(filename), 0755)
-	if err != nil {
-		return err
-	}
-
-	f, err := atomicfile.New(filename, 0600)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	return encode(f, cfg)
+	return config.WriteFile(filename, cfg)
 }
 
-// encode configuration with JSON
-func encode(w io.Writer, value interface{}) error {
-	// need to prettyprint, hence MarshalIndent, instead of Encoder
-	buf, err := config.Marshal(value)
-	if err != nil {
-		return err
-	}
-	_, err = w.Write(buf)
-	return err
-}
-
-// Load reads given file and returns the read config, or error.
+// Load reads given file and returns the read config, or error. The file is
+// migrated to the current config version in memory first, see config.Decode.
 func Load(filename string) (*config.Config, error) {
-	var cfg config.Config
-	err := ReadConfigFile(filename, &cfg)
+	raw, err := ioutil.ReadFile(filename)
 	if err != nil {
+		if os.IsNotExist(err) {
+			err = ErrNotInitialized
+		}
 		return nil, err
 	}
-
-	return &cfg, err
+	return config.Decode(raw)
 }
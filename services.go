@@ -1,5 +1,13 @@
 package config
 
+import (
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
 type Services struct {
 	StatusServerDomain string
 	HubDomain          string
@@ -12,4 +20,51 @@ type Services struct {
 
 	EscrowPubKeys []string
 	GuardPubKeys  []string
+
+	// TrustedNodes lists the peer IDs of nodes whose service replies are
+	// trusted, e.g. in a staging deployment.
+	TrustedNodes []string `json:",omitempty"`
+}
+
+// Validate checks that every configured domain is either an absolute URL,
+// e.g. "https://hub.btfs.io", or a host:port pair, e.g.
+// "grpc.trongrid.io:50051", and that trusted nodes are peer IDs. Empty
+// domains are allowed.
+func (s Services) Validate() error {
+	var errs ValidationErrors
+	for _, d := range []struct {
+		name, value string
+	}{
+		{"StatusServerDomain", s.StatusServerDomain},
+		{"HubDomain", s.HubDomain},
+		{"EscrowDomain", s.EscrowDomain},
+		{"GuardDomain", s.GuardDomain},
+		{"ExchangeDomain", s.ExchangeDomain},
+		{"SolidityDomain", s.SolidityDomain},
+		{"FullnodeDomain", s.FullnodeDomain},
+		{"TrongridDomain", s.TrongridDomain},
+	} {
+		if d.value == "" {
+			continue
+		}
+		if err := validateServiceDomain(d.value); err != nil {
+			errs.add(fieldErrorf("Services."+d.name, "%s", err))
+		}
+	}
+	for _, id := range s.TrustedNodes {
+		if _, err := peer.Decode(id); err != nil {
+			errs.add(fieldErrorf("Services.TrustedNodes", "invalid peer ID %q: %s", id, err))
+		}
+	}
+	return errs.errOrNil()
+}
+
+func validateServiceDomain(domain string) error {
+	if u, err := url.Parse(domain); err == nil && u.Scheme != "" && u.Host != "" {
+		return nil
+	}
+	if host, port, err := net.SplitHostPort(domain); err == nil && host != "" && port != "" {
+		return nil
+	}
+	return fmt.Errorf("%q is neither a URL nor a host:port", domain)
 }
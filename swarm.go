@@ -1,5 +1,16 @@
 package config
 
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
 type SwarmConfig struct {
 	// AddrFilters specifies a set libp2p addresses that we should never
 	// dial or receive connections from.
@@ -38,13 +49,201 @@ type SwarmConfig struct {
 
 	// ConnMgr configures the connection manager.
 	ConnMgr ConnMgr
+
+	// RelayClient configures the use of circuit relays run by other peers.
+	RelayClient RelayClient
+
+	// RelayService configures the circuit v2 relay service this node
+	// provides to other peers.
+	RelayService RelayService
+
+	// EnableHolePunching enables direct connection upgrades of relayed
+	// connections through hole punching.
+	EnableHolePunching Flag `json:",omitempty"`
+
+	// ResourceMgr configures the libp2p resource manager.
+	ResourceMgr ResourceMgr
+}
+
+// ResourceMgr configures the limits enforced by the libp2p resource manager.
+type ResourceMgr struct {
+	// Enabled enables the resource manager. Defaults to off.
+	Enabled Flag `json:",omitempty"`
+
+	// MaxMemory caps the memory used by the whole libp2p stack, e.g.
+	// "2GB". Accepts the same units as Datastore.StorageMax.
+	MaxMemory string `json:",omitempty"`
+
+	// MaxFileDescriptors caps the file descriptors used by libp2p.
+	MaxFileDescriptors int `json:",omitempty"`
+
+	// Limits overrides the limits of individual scopes, keyed by scope name
+	// such as "system", "transient" or a peer ID.
+	Limits map[string]ResourceLimit `json:",omitempty"`
+}
+
+// ResourceLimit holds the limits of a single resource manager scope. Zero
+// values leave the scope's default limit in place.
+type ResourceLimit struct {
+	Memory string `json:",omitempty"`
+
+	Streams         int `json:",omitempty"`
+	StreamsInbound  int `json:",omitempty"`
+	StreamsOutbound int `json:",omitempty"`
+
+	Conns         int `json:",omitempty"`
+	ConnsInbound  int `json:",omitempty"`
+	ConnsOutbound int `json:",omitempty"`
+
+	FD int `json:",omitempty"`
+}
+
+// Validate checks that the memory limits parse as byte quantities and that
+// no limit is negative.
+func (r ResourceMgr) Validate() error {
+	var errs ValidationErrors
+	if r.MaxMemory != "" {
+		if _, err := parseByteSize(r.MaxMemory); err != nil {
+			errs.add(fieldErrorf("Swarm.ResourceMgr.MaxMemory", "%s", err))
+		}
+	}
+	if r.MaxFileDescriptors < 0 {
+		errs.add(fieldErrorf("Swarm.ResourceMgr.MaxFileDescriptors", "must not be negative, got %d", r.MaxFileDescriptors))
+	}
+
+	scopes := make([]string, 0, len(r.Limits))
+	for scope := range r.Limits {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	for _, scope := range scopes {
+		l := r.Limits[scope]
+		if l.Memory != "" {
+			if _, err := parseByteSize(l.Memory); err != nil {
+				errs.add(fieldErrorf("Swarm.ResourceMgr.Limits."+scope+".Memory", "%s", err))
+			}
+		}
+		if l.Streams < 0 || l.StreamsInbound < 0 || l.StreamsOutbound < 0 ||
+			l.Conns < 0 || l.ConnsInbound < 0 || l.ConnsOutbound < 0 || l.FD < 0 {
+			errs.add(fieldErrorf("Swarm.ResourceMgr.Limits."+scope, "limits must not be negative"))
+		}
+	}
+	return errs.errOrNil()
+}
+
+// RelayClient configures the relays this node reserves slots on when it is
+// not publicly reachable.
+type RelayClient struct {
+	// Enabled enables the relay client. Defaults to on when the node is
+	// not publicly reachable.
+	Enabled Flag `json:",omitempty"`
+
+	// StaticRelays lists the relays to use instead of discovering them,
+	// each a multiaddr ending in /p2p/<peer ID>.
+	StaticRelays []string `json:",omitempty"`
+}
+
+// StaticRelayInfos parses StaticRelays, grouping addresses of the same relay.
+func (r RelayClient) StaticRelayInfos() ([]peer.AddrInfo, error) {
+	maddrs := make([]ma.Multiaddr, len(r.StaticRelays))
+	for i, addr := range r.StaticRelays {
+		maddr, err := parseStaticRelay(addr)
+		if err != nil {
+			return nil, err
+		}
+		maddrs[i] = maddr
+	}
+	return peer.AddrInfosFromP2pAddrs(maddrs...)
+}
+
+// Validate checks that every static relay is a multiaddr with a peer ID.
+func (r RelayClient) Validate() error {
+	var errs ValidationErrors
+	for _, addr := range r.StaticRelays {
+		if _, err := parseStaticRelay(addr); err != nil {
+			errs.add(err)
+		}
+	}
+	return errs.errOrNil()
+}
+
+func parseStaticRelay(addr string) (ma.Multiaddr, error) {
+	maddr, err := ma.NewMultiaddr(addr)
+	if err != nil {
+		return nil, fieldErrorf("Swarm.RelayClient.StaticRelays", "invalid multiaddr %q: %s", addr, err)
+	}
+	if _, err := peer.AddrInfoFromP2pAddr(maddr); err != nil {
+		return nil, fieldErrorf("Swarm.RelayClient.StaticRelays", "%q must end in /p2p/<peer ID>: %s", addr, err)
+	}
+	return maddr, nil
+}
+
+// RelayService configures the resources of the circuit v2 relay service.
+// Zero values leave the libp2p defaults in place.
+type RelayService struct {
+	// Enabled enables the relay service. Defaults to off unless the node
+	// is publicly reachable.
+	Enabled Flag `json:",omitempty"`
+
+	// ConnectionDurationLimit is the time limit before resetting a relayed
+	// connection.
+	ConnectionDurationLimit Duration `json:",omitempty"`
+	// ConnectionDataLimit is the limit of data relayed (in each direction)
+	// before resetting the connection.
+	ConnectionDataLimit int64 `json:",omitempty"`
+
+	// ReservationTTL is the duration of a new (or refreshed) reservation.
+	ReservationTTL Duration `json:",omitempty"`
+
+	// MaxReservations is the maximum number of active relay slots.
+	MaxReservations int `json:",omitempty"`
+	// MaxCircuits is the maximum number of open relay connections for each
+	// peer.
+	MaxCircuits int `json:",omitempty"`
+	// BufferSize is the size of the relayed connection buffers.
+	BufferSize int `json:",omitempty"`
+
+	// MaxReservationsPerPeer is the maximum number of reservations
+	// originating from the same peer.
+	MaxReservationsPerPeer int `json:",omitempty"`
+	// MaxReservationsPerIP is the maximum number of reservations
+	// originating from the same IP address.
+	MaxReservationsPerIP int `json:",omitempty"`
+}
+
+// Validate checks that the relay service limits are not negative.
+func (r RelayService) Validate() error {
+	var errs ValidationErrors
+	if r.ConnectionDurationLimit < 0 {
+		errs.add(fieldErrorf("Swarm.RelayService.ConnectionDurationLimit", "must not be negative, got %s", r.ConnectionDurationLimit))
+	}
+	if r.ReservationTTL < 0 {
+		errs.add(fieldErrorf("Swarm.RelayService.ReservationTTL", "must not be negative, got %s", r.ReservationTTL))
+	}
+	limits := []struct {
+		name  string
+		value int64
+	}{
+		{"ConnectionDataLimit", r.ConnectionDataLimit},
+		{"MaxReservations", int64(r.MaxReservations)},
+		{"MaxCircuits", int64(r.MaxCircuits)},
+		{"BufferSize", int64(r.BufferSize)},
+		{"MaxReservationsPerPeer", int64(r.MaxReservationsPerPeer)},
+		{"MaxReservationsPerIP", int64(r.MaxReservationsPerIP)},
+	}
+	for _, l := range limits {
+		if l.value < 0 {
+			errs.add(fieldErrorf("Swarm.RelayService."+l.name, "must not be negative, got %d", l.value))
+		}
+	}
+	return errs.errOrNil()
 }
 
 type Transports struct {
 	// Network specifies the base transports we'll use for dialing. To
 	// listen on a transport, add the transport to your Addresses.Swarm.
 	Network struct {
-		// All default to on.
+		// All but Websocket default to on.
 		QUIC      Flag `json:",omitempty"`
 		TCP       Flag `json:",omitempty"`
 		Websocket Flag `json:",omitempty"`
@@ -70,8 +269,47 @@ type Transports struct {
 		// Defaults to 200.
 		Mplex Priority `json:",omitempty"`
 	}
+
+	// unknownNetwork holds the keys of Network that name no transport,
+	// reported by Validate.
+	unknownNetwork []string
+}
+
+// transportsFields is Transports without its JSON methods.
+type transportsFields Transports
+
+// UnmarshalJSON decodes the transports, remembering the Network keys that
+// name no known transport so Validate can report them instead of them being
+// silently dropped.
+func (t *Transports) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*transportsFields)(t)); err != nil {
+		return err
+	}
+	var raw struct {
+		Network map[string]json.RawMessage
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	t.unknownNetwork = nil
+	for name := range raw.Network {
+		if _, err := t.networkTransport(name); err != nil {
+			t.unknownNetwork = append(t.unknownNetwork, name)
+		}
+	}
+	sort.Strings(t.unknownNetwork)
+	return nil
 }
 
+// Connection manager types accepted in ConnMgr.Type.
+const (
+	// ConnMgrTypeBasic trims connections between the low and high water
+	// marks.
+	ConnMgrTypeBasic = "basic"
+	// ConnMgrTypeNone disables connection trimming.
+	ConnMgrTypeNone = "none"
+)
+
 // ConnMgr defines configuration options for the libp2p connection manager
 type ConnMgr struct {
 	Type        string
@@ -79,3 +317,165 @@ type ConnMgr struct {
 	HighWater   int
 	GracePeriod string
 }
+
+// Validate checks the connection manager settings. The water marks and grace
+// period are ignored when the connection manager is disabled.
+func (c ConnMgr) Validate() error {
+	switch c.Type {
+	case "", ConnMgrTypeBasic:
+	case ConnMgrTypeNone:
+		return nil
+	default:
+		return fieldErrorf("Swarm.ConnMgr.Type", "unknown connection manager type %q", c.Type)
+	}
+
+	var errs ValidationErrors
+	if c.LowWater < 0 || c.HighWater < 0 {
+		errs.add(fieldErrorf("Swarm.ConnMgr", "water marks must not be negative, got %d and %d", c.LowWater, c.HighWater))
+	}
+	if c.LowWater > c.HighWater {
+		errs.add(fieldErrorf("Swarm.ConnMgr", "LowWater (%d) must not exceed HighWater (%d)", c.LowWater, c.HighWater))
+	}
+	if c.GracePeriod != "" {
+		if _, err := time.ParseDuration(c.GracePeriod); err != nil {
+			errs.add(fieldErrorf("Swarm.ConnMgr.GracePeriod", "%s", err))
+		}
+	}
+	return errs.errOrNil()
+}
+
+// SetConnMgrType sets Swarm.ConnMgr.Type. Switching to "none" clears the
+// water marks and grace period, which mean nothing without a connection
+// manager; switching back to "basic" restores their defaults when unset.
+func (c *Config) SetConnMgrType(typ string) error {
+	cm := ConnMgr{Type: typ}
+	if err := cm.Validate(); err != nil {
+		return err
+	}
+	switch typ {
+	case ConnMgrTypeNone:
+		c.Swarm.ConnMgr = cm
+	default:
+		c.Swarm.ConnMgr.Type = typ
+		def := DefaultSwarmConfig().ConnMgr
+		if c.Swarm.ConnMgr.LowWater == 0 && c.Swarm.ConnMgr.HighWater == 0 {
+			c.Swarm.ConnMgr.LowWater, c.Swarm.ConnMgr.HighWater = def.LowWater, def.HighWater
+		}
+		if c.Swarm.ConnMgr.GracePeriod == "" {
+			c.Swarm.ConnMgr.GracePeriod = def.GracePeriod
+		}
+	}
+	return nil
+}
+
+// ConnMgrOptions holds the settings handed to the libp2p connection manager.
+type ConnMgrOptions struct {
+	// Enabled is false for the "none" type, in which case the node runs
+	// without a connection manager and the other fields are zero.
+	Enabled     bool
+	LowWater    int
+	HighWater   int
+	GracePeriod time.Duration
+}
+
+// Options resolves the connection manager settings, filling unset water
+// marks and grace period with their defaults.
+func (c ConnMgr) Options() (ConnMgrOptions, error) {
+	if err := c.Validate(); err != nil {
+		return ConnMgrOptions{}, err
+	}
+	if c.Type == ConnMgrTypeNone {
+		return ConnMgrOptions{}, nil
+	}
+	opts := ConnMgrOptions{
+		Enabled:     true,
+		LowWater:    c.LowWater,
+		HighWater:   c.HighWater,
+		GracePeriod: DefaultConnMgrGracePeriod,
+	}
+	if opts.LowWater == 0 && opts.HighWater == 0 {
+		opts.LowWater, opts.HighWater = DefaultConnMgrLowWater, DefaultConnMgrHighWater
+	}
+	if c.GracePeriod != "" {
+		// checked by Validate.
+		opts.GracePeriod, _ = time.ParseDuration(c.GracePeriod)
+	}
+	return opts, nil
+}
+
+// networkTransportNames lists the network transports in Transports.Network.
+var networkTransportNames = []string{"QUIC", "TCP", "Websocket", "Relay"}
+
+// networkTransport returns the flag for the named network transport, matched
+// case insensitively.
+func (t *Transports) networkTransport(name string) (*Flag, error) {
+	switch strings.ToLower(name) {
+	case "quic":
+		return &t.Network.QUIC, nil
+	case "tcp":
+		return &t.Network.TCP, nil
+	case "websocket":
+		return &t.Network.Websocket, nil
+	case "relay":
+		return &t.Network.Relay, nil
+	default:
+		return nil, fmt.Errorf("unknown network transport %q", name)
+	}
+}
+
+// IsEnabled reports whether the named network transport (QUIC, TCP,
+// Websocket or Relay) is enabled. Transports left at Default resolve to TCP
+// on, QUIC on, Websocket off and Relay on. Unknown transports are never
+// enabled.
+func (t Transports) IsEnabled(name string) bool {
+	f, err := t.networkTransport(name)
+	if err != nil {
+		return false
+	}
+	return f.WithDefault(!strings.EqualFold(name, "websocket"))
+}
+
+// SetEnabled sets the flag of the named network transport.
+func (t *Transports) SetEnabled(name string, enabled Flag) error {
+	f, err := t.networkTransport(name)
+	if err != nil {
+		return err
+	}
+	*f = enabled
+	return nil
+}
+
+// EnabledTransports returns the names of the network transports the node
+// actually runs, in the order QUIC, TCP, Websocket, Relay. Transports left at
+// Default resolve as in Transports.IsEnabled, except that the deprecated
+// Swarm.DisableRelay still turns Relay off.
+func (c *Config) EnabledTransports() []string {
+	enabled := []string{}
+	for _, name := range networkTransportNames {
+		if name == "Relay" && c.Swarm.Transports.Network.Relay == Default && c.Swarm.DisableRelay {
+			continue
+		}
+		if c.Swarm.Transports.IsEnabled(name) {
+			enabled = append(enabled, name)
+		}
+	}
+	return enabled
+}
+
+// Validate checks that every network transport flag holds a valid value and
+// that Network names no unknown transport.
+func (t Transports) Validate() error {
+	var errs ValidationErrors
+	for _, name := range networkTransportNames {
+		f, _ := t.networkTransport(name)
+		switch *f {
+		case Default, True, False:
+		default:
+			errs.add(fieldErrorf("Swarm.Transports.Network."+name, "invalid flag value %d", *f))
+		}
+	}
+	for _, name := range t.unknownNetwork {
+		errs.add(fieldErrorf("Swarm.Transports.Network."+name, "unknown network transport"))
+	}
+	return errs.errOrNil()
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SwarmKeyHeader is the first line of a v1 pre-shared swarm key.
+const SwarmKeyHeader = "/key/swarm/psk/1.0.0/"
+
+const swarmKeyLen = 32
+
+// ErrNoSwarmKey is returned by PrivateNetworkKey when Swarm.SwarmKey is unset.
+var ErrNoSwarmKey = errors.New("no swarm key configured")
+
+// GenerateSwarmKey returns a new random v1 pre-shared swarm key in the
+// base16 encoding, suitable for Swarm.SwarmKey or a swarm.key file.
+func GenerateSwarmKey() (string, error) {
+	key := make([]byte, swarmKeyLen)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return SwarmKeyHeader + "\n/base16/\n" + hex.EncodeToString(key) + "\n", nil
+}
+
+// PrivateNetworkKey decodes Swarm.SwarmKey and returns the raw 32 byte key
+// used by the libp2p private network transport.
+func (c *Config) PrivateNetworkKey() ([]byte, error) {
+	if c.Swarm.SwarmKey == "" {
+		return nil, ErrNoSwarmKey
+	}
+	return decodeSwarmKey(c.Swarm.SwarmKey)
+}
+
+// decodeSwarmKey parses a v1 pre-shared key in the base16 or base64
+// encoding.
+func decodeSwarmKey(s string) ([]byte, error) {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) != 3 || lines[0] != SwarmKeyHeader {
+		return nil, fmt.Errorf("swarm key must be %s followed by an encoding and a key", SwarmKeyHeader)
+	}
+
+	var key []byte
+	var err error
+	switch lines[1] {
+	case "/base16/":
+		key, err = hex.DecodeString(lines[2])
+	case "/base64/":
+		key, err = base64.StdEncoding.DecodeString(lines[2])
+	default:
+		return nil, fmt.Errorf("unsupported swarm key encoding %s", lines[1])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("malformed swarm key: %s", err)
+	}
+	if len(key) != swarmKeyLen {
+		return nil, fmt.Errorf("swarm key must be %d bytes, got %d", swarmKeyLen, len(key))
+	}
+	return key, nil
+}
+
+// IsPrivateNetwork reports whether the node uses a swarm key other than the
+// mainnet and testnet BTFS keys.
+func (c *Config) IsPrivateNetwork() bool {
+	switch c.Swarm.SwarmKey {
+	case "", DefaultSwarmKey, DefaultTestnetSwarmKey:
+		return false
+	}
+	return true
+}
+
+// validatePrivateNetwork checks that the swarm key, when set, decodes.
+// Bootstrapping a private network from the public BTFS peers is only
+// reported by Warnings.
+func (c *Config) validatePrivateNetwork() error {
+	if c.Swarm.SwarmKey == "" {
+		return nil
+	}
+	if _, err := decodeSwarmKey(c.Swarm.SwarmKey); err != nil {
+		return fieldErrorf("Swarm.SwarmKey", "%s", err)
+	}
+	return nil
+}
+
+// isPublicBootstrapAddress reports whether addr is one of the mainnet or
+// testnet bootstrap addresses.
+func isPublicBootstrapAddress(addr string) bool {
+	return hasString(DefaultBootstrapAddresses, addr) || hasString(DefaultTestnetBootstrapAddresses, addr)
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestSwarmKey(t *testing.T) {
+	key, err := GenerateSwarmKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, SwarmKeyHeader+"\n/base16/\n") {
+		t.Fatalf("unexpected swarm key format %q", key)
+	}
+
+	c := new(Config)
+	if _, err := c.PrivateNetworkKey(); err != ErrNoSwarmKey {
+		t.Fatalf("expected ErrNoSwarmKey, got %v", err)
+	}
+	c.Swarm.SwarmKey = key
+	raw, err := c.PrivateNetworkKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) != 32 {
+		t.Fatalf("expected a 32 byte key, got %d", len(raw))
+	}
+
+	c.Swarm.SwarmKey = SwarmKeyHeader + "\n/base64/\n" + base64.StdEncoding.EncodeToString(raw)
+	raw64, err := c.PrivateNetworkKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(raw, raw64) {
+		t.Fatal("expected base64 and base16 keys to decode identically")
+	}
+
+	for _, k := range []string{DefaultSwarmKey, DefaultTestnetSwarmKey} {
+		if _, err := decodeSwarmKey(k); err != nil {
+			t.Fatalf("expected default swarm key to decode: %s", err)
+		}
+	}
+
+	another, _ := GenerateSwarmKey()
+	if another == key {
+		t.Fatal("expected generated keys to differ")
+	}
+
+	for _, bad := range []string{
+		"deadbeef",
+		SwarmKeyHeader + "\n/base16/\nzz",
+		SwarmKeyHeader + "\n/base16/\ndeadbeef",
+		SwarmKeyHeader + "\n/bin/\nabc",
+	} {
+		if _, err := decodeSwarmKey(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestValidatePrivateNetwork(t *testing.T) {
+	key, err := GenerateSwarmKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := new(Config)
+	c.Swarm.SwarmKey = key
+	c.Bootstrap = []string{DefaultBootstrapAddresses[0]}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Warnings()) != 1 {
+		t.Fatalf("expected a warning about public bootstrap peers, got %v", c.Warnings())
+	}
+	c.Bootstrap = []string{}
+	if len(c.Warnings()) != 0 {
+		t.Fatalf("expected no warnings, got %v", c.Warnings())
+	}
+
+	c.Swarm.SwarmKey = DefaultSwarmKey
+	c.Bootstrap = DefaultBootstrapAddresses
+	if c.IsPrivateNetwork() {
+		t.Fatal("expected the mainnet swarm key not to be a private network")
+	}
+	if len(c.Warnings()) != 0 {
+		t.Fatalf("expected no warnings, got %v", c.Warnings())
+	}
+
+	c.Swarm.SwarmKey = "garbage"
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected malformed swarm key to be rejected")
+	}
+}
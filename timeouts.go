@@ -0,0 +1,58 @@
+package config
+
+import "time"
+
+// Default HTTP server timeouts of the API and the gateway. Responses are
+// streamed, so writes aren't bounded by default.
+const (
+	DefaultHTTPReadTimeout  = 30 * time.Second
+	DefaultHTTPWriteTimeout = time.Duration(0)
+	DefaultHTTPIdleTimeout  = 2 * time.Minute
+)
+
+// ServerTimeouts returns the read, write and idle timeouts of the API HTTP
+// server. Unset values fall back to the defaults and zero means no timeout.
+func (a API) ServerTimeouts() (read, write, idle time.Duration, err error) {
+	return serverTimeouts("API", a.ReadTimeout, a.WriteTimeout, a.IdleTimeout)
+}
+
+// ServerTimeouts returns the read, write and idle timeouts of the gateway
+// HTTP server, like API.ServerTimeouts.
+func (g Gateway) ServerTimeouts() (read, write, idle time.Duration, err error) {
+	return serverTimeouts("Gateway", g.ReadTimeout, g.WriteTimeout, g.IdleTimeout)
+}
+
+// Validate checks the API server timeouts and auth scopes.
+func (a API) Validate() error {
+	var errs ValidationErrors
+	_, _, _, err := a.ServerTimeouts()
+	errs.add(err)
+	errs.add(a.validateAuthorizations())
+	return errs.errOrNil()
+}
+
+func serverTimeouts(section, read, write, idle string) (r, w, i time.Duration, err error) {
+	var errs ValidationErrors
+	parse := func(name, value string, def time.Duration) time.Duration {
+		if value == "" {
+			return def
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			errs.add(fieldErrorf(section+"."+name, "%s", err))
+			return 0
+		}
+		if d < 0 {
+			errs.add(fieldErrorf(section+"."+name, "must not be negative, got %s", value))
+			return 0
+		}
+		return d
+	}
+	r = parse("ReadTimeout", read, DefaultHTTPReadTimeout)
+	w = parse("WriteTimeout", write, DefaultHTTPWriteTimeout)
+	i = parse("IdleTimeout", idle, DefaultHTTPIdleTimeout)
+	if err := errs.errOrNil(); err != nil {
+		return 0, 0, 0, err
+	}
+	return r, w, i, nil
+}
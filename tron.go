@@ -0,0 +1,39 @@
+package config
+
+import (
+	"crypto/sha256"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec"
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/mr-tron/base58/base58"
+	"golang.org/x/crypto/sha3"
+)
+
+// TronAddressPrefix is the version byte of TRON mainnet addresses.
+const TronAddressPrefix = 0x41
+
+// ErrNotSecp256k1 is returned by TronAddress for keys TRON can't use.
+var ErrNotSecp256k1 = errors.New("TRON addresses require a Secp256k1 key")
+
+// TronAddress returns the base58check TRON address ("T...") controlled by
+// the node key. Only Secp256k1 identities have one.
+func (i Identity) TronAddress() (string, error) {
+	pk, err := i.PublicKey()
+	if err != nil {
+		return "", err
+	}
+	secpk, ok := pk.(*ic.Secp256k1PublicKey)
+	if !ok {
+		return "", ErrNotSecp256k1
+	}
+
+	// keccak256 of the uncompressed point, without the 0x04 marker.
+	h := sha3.NewLegacyKeccak256()
+	h.Write((*btcec.PublicKey)(secpk).SerializeUncompressed()[1:])
+	payload := append([]byte{TronAddressPrefix}, h.Sum(nil)[12:]...)
+
+	first := sha256.Sum256(payload)
+	checksum := sha256.Sum256(first[:])
+	return base58.Encode(append(payload, checksum[:4]...)), nil
+}
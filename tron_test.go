@@ -0,0 +1,38 @@
+package config
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestTronAddress(t *testing.T) {
+	// private key 1, whose Ethereum address 0x7E5F...5Bdf is well known.
+	key := "0000000000000000000000000000000000000000000000000000000000000001"
+	ident, err := IdentityConfig(ioutil.Discard, 0, "", key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := ident.TronAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "TMVQGm1qAQYVdetCeGRRkTWYYrLXuHK2HC"
+	if addr != expected {
+		t.Fatalf("expected %s, got %s", expected, addr)
+	}
+
+	if err := EncryptIdentity(&ident, "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	if encAddr, err := ident.TronAddress(); err != nil || encAddr != expected {
+		t.Fatalf("expected encrypted identity to have address %s, got %s (%v)", expected, encAddr, err)
+	}
+
+	ed, err := IdentityConfig(ioutil.Discard, 0, "Ed25519", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ed.TronAddress(); err != ErrNotSecp256k1 {
+		t.Fatalf("expected ErrNotSecp256k1, got %v", err)
+	}
+}
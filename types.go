@@ -7,8 +7,8 @@ import (
 	"time"
 )
 
-// Strings is a helper type that (un)marshals a single string to/from a single
-// JSON string and a slice of strings to/from a JSON array of strings.
+// Strings is a helper type that unmarshals either a single JSON string or a
+// JSON array of strings, and always marshals to a JSON array.
 type Strings []string
 
 // UnmarshalJSON conforms to the json.Unmarshaler interface.
@@ -30,14 +30,10 @@ func (o *Strings) UnmarshalJSON(data []byte) error {
 
 // MarshalJSON conforms to the json.Marshaler interface.
 func (o Strings) MarshalJSON() ([]byte, error) {
-	switch len(o) {
-	case 0:
-		return json.Marshal(nil)
-	case 1:
-		return json.Marshal(o[0])
-	default:
-		return json.Marshal([]string(o))
+	if o == nil {
+		return []byte("[]"), nil
 	}
+	return json.Marshal([]string(o))
 }
 
 var _ json.Unmarshaler = (*Strings)(nil)
@@ -208,8 +204,8 @@ func (p Priority) String() string {
 	}
 }
 
-var _ json.Unmarshaler = (*Flag)(nil)
-var _ json.Marshaler = (*Flag)(nil)
+var _ json.Unmarshaler = (*Priority)(nil)
+var _ json.Marshaler = (*Priority)(nil)
 
 // Duration wraps time.Duration to provide json serialization and deserialization.
 //
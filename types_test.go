@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -43,21 +44,23 @@ func TestOneStrings(t *testing.T) {
 		t.Fatal(err)
 
 	}
-	expected := "\"one\""
+	expected := "[\"one\"]"
 	if string(out) != expected {
 		t.Fatalf("expected %s, got %s", expected, string(out))
 	}
 }
 
 func TestNoStrings(t *testing.T) {
-	out, err := json.Marshal(Strings{})
-	if err != nil {
-		t.Fatal(err)
+	for _, s := range []Strings{nil, {}} {
+		out, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
 
-	}
-	expected := "null"
-	if string(out) != expected {
-		t.Fatalf("expected %s, got %s", expected, string(out))
+		}
+		expected := "[]"
+		if string(out) != expected {
+			t.Fatalf("expected %s, got %s", expected, string(out))
+		}
 	}
 }
 
@@ -73,6 +76,51 @@ func TestManyStrings(t *testing.T) {
 	}
 }
 
+func TestStringsUnmarshal(t *testing.T) {
+	for in, expected := range map[string]Strings{
+		`"/ip4/127.0.0.1/tcp/5001"`:   {"/ip4/127.0.0.1/tcp/5001"},
+		`["/ip4/127.0.0.1/tcp/5001"]`: {"/ip4/127.0.0.1/tcp/5001"},
+		`["one","two"]`:               {"one", "two"},
+		`""`:                          {},
+		`[]`:                          {},
+	} {
+		var s Strings
+		if err := json.Unmarshal([]byte(in), &s); err != nil {
+			t.Fatal(err)
+		}
+		if len(s) != len(expected) {
+			t.Fatalf("%s: expected %v, got %v", in, expected, s)
+		}
+		for i := range s {
+			if s[i] != expected[i] {
+				t.Fatalf("%s: expected %v, got %v", in, expected, s)
+			}
+		}
+
+		// marshaling is stable once normalized to an array.
+		out, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back Strings
+		if err := json.Unmarshal(out, &back); err != nil {
+			t.Fatal(err)
+		}
+		again, err := json.Marshal(back)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(again) != string(out) || out[0] != '[' {
+			t.Fatalf("%s: unstable round trip %s -> %s", in, out, again)
+		}
+	}
+
+	var s Strings
+	if err := json.Unmarshal([]byte(`5001`), &s); err == nil {
+		t.Fatal("expected a number to be rejected")
+	}
+}
+
 func TestFunkyStrings(t *testing.T) {
 	toParse := " [   \"one\",   \"two\" ]  "
 	var s Strings
@@ -150,6 +198,33 @@ func TestFlag(t *testing.T) {
 	if string(out) != expected {
 		t.Fatal("expected omitempty to omit the flag")
 	}
+
+	for _, f := range []Flag{Default, True, False} {
+		in := Foo{F: f}
+		out, err := json.Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back Foo
+		if err := json.Unmarshal(out, &back); err != nil {
+			t.Fatal(err)
+		}
+		if back != in {
+			t.Fatalf("expected %s to round trip, got %s", f, back.F)
+		}
+	}
+
+	for _, invalid := range []string{
+		"0", "1", `"true"`, "undefined",
+	} {
+		var f Flag
+		if err := json.Unmarshal([]byte(invalid), &f); err == nil {
+			t.Errorf("expected to fail to decode %s as a flag", invalid)
+		}
+	}
+	if _, err := json.Marshal(Flag(3)); err == nil {
+		t.Error("expected invalid flag to fail to encode")
+	}
 }
 
 func TestPriority(t *testing.T) {
@@ -218,3 +293,215 @@ func TestPriority(t *testing.T) {
 		}
 	}
 }
+
+func TestAutoNATConfig(t *testing.T) {
+	var c AutoNATConfig
+	err := json.Unmarshal([]byte(`{"ServiceMode":"enabled","Throttle":{"GlobalLimit":30,"PeerLimit":3,"Interval":"1m"}}`), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ServiceMode != AutoNATServiceEnabled || time.Duration(c.Throttle.Interval) != time.Minute {
+		t.Fatalf("unexpected autonat config %+v", c)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"ServiceMode":"sometimes"}`), &c); err == nil {
+		t.Fatal("expected unknown service mode to be rejected")
+	}
+	if err := json.Unmarshal([]byte(`{"Throttle":{"Interval":"soon"}}`), &c); err == nil {
+		t.Fatal("expected bad interval to be rejected")
+	}
+
+	c = AutoNATConfig{ServiceMode: 7, Throttle: &AutoNATThrottleConfig{PeerLimit: -1}}
+	if errs, ok := c.Validate().(ValidationErrors); !ok || len(errs) != 2 {
+		t.Fatalf("expected two validation errors, got %v", c.Validate())
+	}
+}
+
+func TestTransportsIsEnabled(t *testing.T) {
+	var tr Transports
+	for _, name := range []string{"QUIC", "tcp", "relay"} {
+		if !tr.IsEnabled(name) {
+			t.Fatalf("expected %s to be enabled by default", name)
+		}
+	}
+	if tr.IsEnabled("websocket") {
+		t.Fatal("expected Websocket to be disabled by default")
+	}
+	if err := tr.SetEnabled("tcp", False); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.SetEnabled("relay", False); err != nil {
+		t.Fatal(err)
+	}
+	if tr.IsEnabled("TCP") || tr.IsEnabled("Relay") || !tr.IsEnabled("QUIC") {
+		t.Fatalf("unexpected transports %+v", tr.Network)
+	}
+	if err := tr.SetEnabled("carrier-pigeon", True); err == nil {
+		t.Fatal("expected unknown transport to be rejected")
+	}
+	if tr.IsEnabled("carrier-pigeon") {
+		t.Fatal("expected unknown transport to be disabled")
+	}
+
+	out, err := json.Marshal(tr.Network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"TCP":false,"Relay":false}`
+	if string(out) != expected {
+		t.Fatalf("expected %s, got %s", expected, out)
+	}
+}
+
+func TestEnabledTransports(t *testing.T) {
+	c := NewDefault()
+	if got := c.EnabledTransports(); !reflect.DeepEqual(got, []string{"QUIC", "TCP", "Relay"}) {
+		t.Fatalf("expected every transport but Websocket to be enabled by default, got %v", got)
+	}
+
+	c.Swarm.Transports.Network.QUIC = False
+	if got := c.EnabledTransports(); !reflect.DeepEqual(got, []string{"TCP", "Relay"}) {
+		t.Fatalf("expected QUIC to be disabled, got %v", got)
+	}
+
+	c.Swarm.DisableRelay = true
+	if got := c.EnabledTransports(); !reflect.DeepEqual(got, []string{"TCP"}) {
+		t.Fatalf("expected DisableRelay to disable the relay transport, got %v", got)
+	}
+	c.Swarm.Transports.Network.Relay = True
+	c.Swarm.Transports.Network.Websocket = True
+	if got := c.EnabledTransports(); !reflect.DeepEqual(got, []string{"TCP", "Websocket", "Relay"}) {
+		t.Fatalf("expected explicit flags to override the defaults, got %v", got)
+	}
+}
+
+func TestTransportsUnknownNetwork(t *testing.T) {
+	var c Config
+	if err := json.Unmarshal([]byte(`{"Swarm":{"Transports":{"Network":{"tcp":true,"Websockets":true}}}}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Swarm.Transports.Network.TCP != True {
+		t.Fatal("expected known transports to still be decoded case insensitively")
+	}
+	errs, ok := c.Swarm.Transports.Validate().(ValidationErrors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("expected one validation error, got %v", c.Swarm.Transports.Validate())
+	}
+	if fe, ok := errs[0].(FieldError); !ok || fe.Path != "Swarm.Transports.Network.Websockets" {
+		t.Fatalf("expected the misspelt transport to be reported, got %v", errs[0])
+	}
+
+	if err := json.Unmarshal([]byte(`{"Network":{"QUIC":false}}`), &c.Swarm.Transports); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Swarm.Transports.Validate(); err != nil {
+		t.Fatalf("expected decoding again to forget the unknown transport, got %s", err)
+	}
+}
+
+func TestRelayService(t *testing.T) {
+	out, err := json.Marshal(RelayService{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "{}" {
+		t.Fatalf("expected empty relay service to marshal to {}, got %s", out)
+	}
+
+	var r RelayService
+	err = json.Unmarshal([]byte(`{"Enabled":true,"ReservationTTL":"1h","MaxReservations":128,"BufferSize":2048}`), &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Enabled != True || time.Duration(r.ReservationTTL) != time.Hour || r.MaxReservations != 128 {
+		t.Fatalf("unexpected relay service %+v", r)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"ConnectionDurationLimit":"2 minutes"}`), &r); err == nil {
+		t.Fatal("expected bad duration to be rejected")
+	}
+	r = RelayService{MaxCircuits: -1, ReservationTTL: Duration(-time.Second)}
+	if errs, ok := r.Validate().(ValidationErrors); !ok || len(errs) != 2 {
+		t.Fatalf("expected two validation errors, got %v", r.Validate())
+	}
+}
+
+func TestResourceMgr(t *testing.T) {
+	out, err := json.Marshal(ResourceMgr{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "{}" {
+		t.Fatalf("expected empty resource manager to marshal to {}, got %s", out)
+	}
+
+	in := ResourceMgr{
+		Enabled:            True,
+		MaxMemory:          "2GB",
+		MaxFileDescriptors: 4096,
+		Limits: map[string]ResourceLimit{
+			"system": {Memory: "512MiB", Conns: 512, FD: 1024},
+		},
+	}
+	out, err = json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back ResourceMgr
+	if err := json.Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Enabled != True || back.MaxMemory != "2GB" || back.Limits["system"] != in.Limits["system"] {
+		t.Fatalf("expected resource manager to round trip, got %s", out)
+	}
+	if err := back.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	back.MaxMemory = "lots"
+	if err := back.Validate(); err == nil {
+		t.Fatal("expected bad MaxMemory to be rejected")
+	}
+}
+
+func TestInternal(t *testing.T) {
+	out, err := json.Marshal(Internal{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "{}" {
+		t.Fatalf("expected empty internal section to marshal to {}, got %s", out)
+	}
+
+	in := Internal{
+		Bitswap:                     &InternalBitswap{TaskWorkerCount: 8, MaxOutstandingBytesPerPeer: 1 << 20},
+		UnixFSShardingSizeThreshold: "256KiB",
+	}
+	out, err = json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"Bitswap":{"TaskWorkerCount":8,"MaxOutstandingBytesPerPeer":1048576},"UnixFSShardingSizeThreshold":"256KiB"}`
+	if string(out) != expected {
+		t.Fatalf("expected %s, got %s", expected, out)
+	}
+	var back Internal
+	if err := json.Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if err := back.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	back.Bitswap.EngineTaskWorkerCount = -1
+	back.UnixFSShardingSizeThreshold = "big"
+	if errs, ok := back.Validate().(ValidationErrors); !ok || len(errs) != 2 {
+		t.Fatalf("expected two validation errors, got %v", back.Validate())
+	}
+}
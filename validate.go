@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationErrors collects every problem found while validating a config.
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("invalid config: %s", strings.Join(msgs, "; "))
+}
+
+// FieldError is a validation problem with the config path it applies to.
+type FieldError struct {
+	Path    string // dot separated config path
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// fieldErrorf formats a FieldError for path.
+func fieldErrorf(path, format string, args ...interface{}) error {
+	return FieldError{Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+// add appends err to the list, flattening nested ValidationErrors.
+func (e *ValidationErrors) add(err error) {
+	if err == nil {
+		return
+	}
+	if errs, ok := err.(ValidationErrors); ok {
+		*e = append(*e, errs...)
+		return
+	}
+	*e = append(*e, err)
+}
+
+// errOrNil returns nil when no problem was collected.
+func (e ValidationErrors) errOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Validate checks the config for obvious misconfigurations, such as malformed
+// addresses or unparseable durations, and reports all of them at once.
+// Daemons should call it right after loading a config so that problems
+// surface before any service starts.
+func (c *Config) Validate() error {
+	var errs ValidationErrors
+	errs.add(c.validateIdentities())
+	errs.add(c.Addresses.Validate())
+	errs.add(c.Datastore.Validate())
+	errs.add(c.Mounts.Validate())
+	errs.add(c.Discovery.MDNS.Validate())
+	errs.add(c.Swarm.ConnMgr.Validate())
+	errs.add(c.Swarm.Transports.Validate())
+	errs.add(c.Swarm.RelayClient.Validate())
+	errs.add(c.Swarm.RelayService.Validate())
+	errs.add(c.Swarm.ResourceMgr.Validate())
+	errs.add(c.Bitswap.Validate())
+	errs.add(c.validatePrivateNetwork())
+	errs.add(c.Provider.Validate())
+	errs.add(c.Reprovider.Validate())
+	errs.add(c.Ipns.Validate())
+	errs.add(c.Routing.Validate())
+	errs.add(c.Gateway.Validate())
+	errs.add(c.API.Validate())
+	errs.add(c.Pubsub.Validate())
+	errs.add(c.AutoNAT.Validate())
+	errs.add(c.Import.Validate())
+	errs.add(c.Logging.Validate())
+	errs.add(c.DNS.Validate())
+	errs.add(c.Internal.Validate())
+	errs.add(c.Services.Validate())
+	errs.add(c.Pinning.Validate())
+	return errs.errOrNil()
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// Warnings returns advisory messages about settings that are legal but
+// probably not what the user wants. Unlike Validate, a config with warnings is
+// still usable.
+func (c *Config) Warnings() []string {
+	var warnings []string
+	for _, f := range c.warningFindings() {
+		warnings = append(warnings, f.String())
+	}
+	return warnings
+}
+
+// warningFindings returns the findings behind Warnings.
+func (c *Config) warningFindings() []Finding {
+	var findings []Finding
+	if c.HasPlaintextPrivateKey() {
+		findings = append(findings, Finding{CodePlaintextPrivateKey, "Identity.PrivKey", "private key is stored unencrypted, see EncryptIdentity"})
+	}
+	findings = append(findings, c.Addresses.listenConflicts()...)
+	findings = append(findings, c.Addresses.privateAnnounceWarnings()...)
+
+	cm := c.Swarm.ConnMgr
+	if cm.Type != ConnMgrTypeNone && cm.HighWater > 0 && cm.HighWater < minConnMgrHighWater {
+		findings = append(findings, Finding{CodeLowHighWater, "Swarm.ConnMgr.HighWater",
+			fmt.Sprintf("%d connections is very low, the node may struggle to stay connected to the network", cm.HighWater)})
+	}
+
+	if c.Discovery.MDNS.Enabled && len(c.Swarm.AddrFilters) > 0 {
+		findings = append(findings, Finding{CodeMDNSFiltered, "Discovery.MDNS",
+			"enabled while Swarm.AddrFilters is set, local peers found over MDNS may be filtered out; the server profile disables MDNS"})
+	}
+
+	if c.IsPrivateNetwork() {
+		for _, addr := range c.Bootstrap {
+			if isPublicBootstrapAddress(addr) {
+				findings = append(findings, Finding{CodePublicBootstrap, "Bootstrap",
+					fmt.Sprintf("%s is a public bootstrap peer, unreachable from a private network", addr)})
+			}
+		}
+	}
+	return findings
+}
+
+// minConnMgrHighWater is the HighWater below which Warnings complains.
+const minConnMgrHighWater = 20
+
+// privateAnnounceWarnings reports announced addresses in private or
+// unroutable ranges that NoAnnounce doesn't hold back.
+func (a Addresses) privateAnnounceWarnings() []Finding {
+	var private []*net.IPNet
+	for _, f := range defaultServerFilters {
+		if n, err := ipcidrNet(f); err == nil {
+			private = append(private, n)
+		}
+	}
+	contains := func(nets []*net.IPNet, ip net.IP) bool {
+		for _, n := range nets {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var warnings []Finding
+	for _, addr := range append(append([]string{}, a.Announce...), a.AppendAnnounce...) {
+		maddr, err := ma.NewMultiaddr(addr)
+		if err != nil {
+			continue
+		}
+		ip := multiaddrIP(maddr)
+		if ip == nil || !(ip.IsLoopback() || contains(private, ip)) {
+			continue
+		}
+		if announced, err := a.ShouldAnnounce(maddr); err != nil || !announced {
+			continue
+		}
+		warnings = append(warnings, Finding{CodePrivateAnnounce, "Addresses",
+			fmt.Sprintf("announcing %s, which is not publicly routable; add it to Addresses.NoAnnounce", addr)})
+	}
+	return warnings
+}
+
+func hasString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ListenConflicts reports Swarm listen addresses that overlap: the same
+// address listed twice, or a specific address on the same transport and port
+// as a wildcard (0.0.0.0 or ::) address that already covers it.
+func (a Addresses) ListenConflicts() []string {
+	var conflicts []string
+	for _, f := range a.listenConflicts() {
+		conflicts = append(conflicts, f.String())
+	}
+	return conflicts
+}
+
+// listenConflicts returns the findings behind ListenConflicts.
+func (a Addresses) listenConflicts() []Finding {
+	type listener struct {
+		addr     string
+		ip       net.IP
+		family   string
+		endpoint string
+	}
+	var listeners []listener
+	for _, addr := range a.Swarm {
+		maddr, err := ma.NewMultiaddr(addr)
+		if err != nil {
+			// reported by Validate.
+			continue
+		}
+		first, rest := ma.SplitFirst(maddr)
+		if first == nil || rest == nil {
+			continue
+		}
+		var family string
+		switch first.Protocol().Code {
+		case ma.P_IP4:
+			family = "ip4"
+		case ma.P_IP6:
+			family = "ip6"
+		default:
+			continue
+		}
+		endpoint := rest.String()
+		if strings.HasSuffix(endpoint, "/0") || strings.Contains(endpoint, "/0/") {
+			// random ports never collide.
+			continue
+		}
+		listeners = append(listeners, listener{addr, net.IP(first.RawValue()), family, endpoint})
+	}
+
+	var conflicts []Finding
+	for i, l := range listeners {
+		for _, o := range listeners[:i] {
+			if l.family != o.family || l.endpoint != o.endpoint {
+				continue
+			}
+			switch {
+			case l.ip.Equal(o.ip):
+				conflicts = append(conflicts, Finding{CodeListenConflict, "Addresses.Swarm", fmt.Sprintf("%s is listed more than once", l.addr)})
+			case o.ip.IsUnspecified():
+				conflicts = append(conflicts, Finding{CodeListenConflict, "Addresses.Swarm", fmt.Sprintf("%s overlaps with %s, which already listens on every interface", l.addr, o.addr)})
+			case l.ip.IsUnspecified():
+				conflicts = append(conflicts, Finding{CodeListenConflict, "Addresses.Swarm", fmt.Sprintf("%s overlaps with %s, which already listens on every interface", o.addr, l.addr)})
+			}
+		}
+	}
+	return conflicts
+}
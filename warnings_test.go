@@ -0,0 +1,143 @@
+package config
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestListenConflicts(t *testing.T) {
+	a := addressesConfig()
+	if conflicts := a.ListenConflicts(); len(conflicts) != 0 {
+		t.Fatalf("expected no conflicts, got %v", conflicts)
+	}
+
+	a.Swarm = []string{
+		"/ip4/0.0.0.0/tcp/4001",
+		"/ip4/127.0.0.1/tcp/4001",
+		"/ip4/127.0.0.1/tcp/4002",
+		"/ip4/127.0.0.1/udp/4001/quic",
+		"/ip6/::1/tcp/4001",
+		"/ip4/10.0.0.1/tcp/0",
+		"/ip4/0.0.0.0/tcp/0",
+	}
+	conflicts := a.ListenConflicts()
+	if len(conflicts) != 1 || !strings.Contains(conflicts[0], "/ip4/127.0.0.1/tcp/4001 overlaps with /ip4/0.0.0.0/tcp/4001") {
+		t.Fatalf("expected a single wildcard overlap, got %v", conflicts)
+	}
+
+	a.Swarm = []string{"/ip4/1.2.3.4/tcp/4001", "/ip4/1.2.3.4/tcp/4001"}
+	if conflicts := a.ListenConflicts(); len(conflicts) != 1 {
+		t.Fatalf("expected a duplicate listen address, got %v", conflicts)
+	}
+
+	c := new(Config)
+	c.Addresses = a
+	if len(c.Warnings()) != 1 {
+		t.Fatalf("expected the conflict in the config warnings, got %v", c.Warnings())
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	c, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := c.Warnings(); len(w) != 1 || !strings.Contains(w[0], "Identity.PrivKey") {
+		t.Fatalf("expected a fresh config to only warn about its plaintext key, got %v", w)
+	}
+	if err := EncryptIdentity(&c.Identity, "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	if w := c.Warnings(); len(w) != 0 {
+		t.Fatalf("expected an encrypted config to have no warnings, got %v", w)
+	}
+
+	c.Addresses.Announce = []string{"/ip4/192.168.1.10/tcp/4001", "/ip4/1.2.3.4/tcp/4001"}
+	c.Addresses.AppendAnnounce = []string{"/ip4/10.1.2.3/tcp/4001"}
+	c.Addresses.NoAnnounce = []string{"/ip4/10.0.0.0/ipcidr/8"}
+	c.Swarm.ConnMgr.HighWater = 5
+	c.Swarm.AddrFilters = defaultServerFilters
+	w := c.Warnings()
+	if len(w) != 3 {
+		t.Fatalf("expected three warnings, got %v", w)
+	}
+	for i, expected := range []string{"192.168.1.10", "Swarm.ConnMgr.HighWater", "Discovery.MDNS"} {
+		if !strings.Contains(w[i], expected) {
+			t.Fatalf("expected warning %d to mention %s, got %q", i, expected, w[i])
+		}
+	}
+
+	c.Swarm.ConnMgr.Type = ConnMgrTypeNone
+	if len(c.Warnings()) != 2 {
+		t.Fatalf("expected no connection manager warning when it is disabled, got %v", c.Warnings())
+	}
+}
+
+func TestDoctor(t *testing.T) {
+	c, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Swarm.ConnMgr.GracePeriod = "soon"
+	c.Reprovider.Strategy = "everything"
+	c.Swarm.ConnMgr.HighWater = 5
+	c.Swarm.ConnMgr.LowWater = 1
+	c.Addresses.Swarm = append(c.Addresses.Swarm, "/ip4/127.0.0.1/tcp/4001")
+
+	r := c.Doctor()
+	if r.OK() || len(r.Errors) != 2 {
+		t.Fatalf("expected two errors, got %v", r.Errors)
+	}
+	paths := map[string]bool{}
+	for _, f := range r.Errors {
+		if f.Code != CodeInvalidValue {
+			t.Fatalf("unexpected error code %q", f.Code)
+		}
+		paths[f.Path] = true
+	}
+	if !paths["Swarm.ConnMgr.GracePeriod"] || !paths["Reprovider.Strategy"] {
+		t.Fatalf("expected errors at the broken paths, got %v", r.Errors)
+	}
+
+	codes := map[string]bool{}
+	for _, f := range r.Warnings {
+		codes[f.Code] = true
+	}
+	for _, code := range []string{CodePlaintextPrivateKey, CodeListenConflict, CodeLowHighWater} {
+		if !codes[code] {
+			t.Fatalf("expected a %s warning, got %v", code, r.Warnings)
+		}
+	}
+	if len(r.Warnings) != len(c.Warnings()) {
+		t.Fatal("expected the report to match Warnings")
+	}
+}
+
+func TestDoctorPathFromStructuredError(t *testing.T) {
+	c, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.DNS.Resolvers = map[string]string{"eth.": "http://example.com:8080/dns-query"}
+	c.API.ReadTimeout = "bogus"
+	c.Gateway.IdleTimeout = "-1s"
+
+	r := c.Doctor()
+	if len(r.Errors) != 3 {
+		t.Fatalf("expected three errors, got %v", r.Errors)
+	}
+	findings := map[string]Finding{}
+	for _, f := range r.Errors {
+		findings[f.Path] = f
+	}
+	if f := findings["DNS.Resolvers.eth."]; f.Message != `"http://example.com:8080/dns-query" is not an https URL` {
+		t.Fatalf("unexpected DNS finding %+v in %v", f, r.Errors)
+	}
+	if f := findings["API.ReadTimeout"]; f.Message == "" || strings.Contains(f.Message, "API.ReadTimeout") {
+		t.Fatalf("unexpected API timeout finding %+v in %v", f, r.Errors)
+	}
+	if f := findings["Gateway.IdleTimeout"]; f.Message != "must not be negative, got -1s" {
+		t.Fatalf("unexpected gateway timeout finding %+v in %v", f, r.Errors)
+	}
+}
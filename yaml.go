@@ -0,0 +1,136 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LoadYAML reads a YAML encoded config, migrates it to CurrentConfigVersion
+// and validates it, like Load. The document is converted to JSON first, so
+// it is decoded by the same rules as a config file: Strings accept a single
+// string, Flag accepts true, false or null, and the maps in Datastore.Spec
+// come out as map[string]interface{}.
+func LoadYAML(r io.Reader) (*Config, error) {
+	in, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal(in, &doc); err != nil {
+		return nil, fmt.Errorf("failure to decode YAML config: %s", err)
+	}
+	doc, err = yamlToJSONValue(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failure to decode YAML config: %s", err)
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failure to decode YAML config: %s", err)
+	}
+	c, err := Decode(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// MarshalYAML encodes the config as a YAML document holding the same keys,
+// in the same order, as the JSON config file. It returns the encoded bytes
+// and so isn't a yaml.Marshaler.
+func (c *Config) MarshalYAML() ([]byte, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	doc, err := decodeOrderedJSON(dec)
+	if err != nil {
+		return nil, fmt.Errorf("failure to encode YAML config: %s", err)
+	}
+	return yaml.Marshal(doc)
+}
+
+// yamlToJSONValue converts the map[interface{}]interface{} values produced
+// by the YAML decoder into map[string]interface{}, recursively.
+func yamlToJSONValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("map key %v is not a string", k)
+			}
+			conv, err := yamlToJSONValue(val)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = conv
+		}
+		return m, nil
+	case []interface{}:
+		for i := range v {
+			conv, err := yamlToJSONValue(v[i])
+			if err != nil {
+				return nil, err
+			}
+			v[i] = conv
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// decodeOrderedJSON decodes the next JSON value from dec, turning objects
+// into yaml.MapSlice so that their key order is kept.
+func decodeOrderedJSON(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		m := yaml.MapSlice{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			val, err := decodeOrderedJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, yaml.MapItem{Key: key, Value: val})
+		}
+		_, err := dec.Token()
+		return m, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			val, err := decodeOrderedJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, val)
+		}
+		_, err := dec.Token()
+		return list, err
+	}
+	if n, ok := tok.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+		return n.Float64()
+	}
+	return tok, nil
+}
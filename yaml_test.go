@@ -0,0 +1,78 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestYAMLRoundTrip(t *testing.T) {
+	orig, err := Init(ioutil.Discard, 2048, "Ed25519", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig.Swarm.Transports.Network.QUIC = False
+	raw, err := json.Marshal(orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := c.MarshalYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(out), "Version: ") {
+		t.Fatalf("expected keys in config file order, got:\n%s", out)
+	}
+	back, err := LoadYAML(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*back, c) {
+		t.Fatalf("config changed across YAML round trip:\n%s", out)
+	}
+	if _, ok := back.Datastore.Spec["mounts"].([]interface{})[0].(map[string]interface{}); !ok {
+		t.Fatalf("expected datastore spec maps to be keyed by string, got %T", back.Datastore.Spec["mounts"])
+	}
+}
+
+func TestLoadYAMLCustomTypes(t *testing.T) {
+	doc := `
+Addresses:
+  API: /ip4/127.0.0.1/tcp/5001
+Swarm:
+  Transports:
+    Network:
+      TCP: false
+      QUIC: null
+`
+	c, err := LoadYAML(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.Addresses.API, Strings{"/ip4/127.0.0.1/tcp/5001"}) {
+		t.Fatalf("expected a single API address, got %v", c.Addresses.API)
+	}
+	if c.Swarm.Transports.Network.TCP != False || c.Swarm.Transports.Network.QUIC != Default {
+		t.Fatalf("unexpected transport flags %+v", c.Swarm.Transports.Network)
+	}
+
+	out, err := c.MarshalYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "TCP: false") {
+		t.Fatalf("expected TCP flag to be encoded as a boolean, got:\n%s", out)
+	}
+
+	if _, err := LoadYAML(strings.NewReader("Bootstrap: {1: 2}")); err == nil {
+		t.Fatal("expected non string map key to be rejected")
+	}
+}